@@ -6,8 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -31,36 +31,90 @@ func init() {
 type App struct {
 	installers map[string]Installer
 	baseDir    string
+	in         io.Reader
 	out        io.Writer
+	errOut     io.Writer
+	client     *http.Client
+	endpoints  Endpoints
+	cache      *downloadCache
+	// modCache is the Go module cache shared by the go servers, kept apart from the download cache
+	modCache string
+
+	insecureSkipVerify bool
+	lock               *Lock
+	// platform overrides the host platform if set
+	platform Support
+
+	// defaults of the options
+	output ListStyle
+	jobs   int
+	proxy  string
+	retry  retryPolicy
 }
 
-func getBaseDir() (string, error) {
-	var baseDir string
+// getDataDir returns the data directory of lsm, which holds the servers and the download cache by default.
+func getDataDir() (string, error) {
+	var dataDir string
 	switch runtime.GOOS {
 	case linux, darwin:
 		xdgDataHome := os.Getenv("XDG_DATA_HOME")
 		if xdgDataHome != "" {
-			baseDir = filepath.Join(xdgDataHome, appName, servers)
+			dataDir = filepath.Join(xdgDataHome, appName)
 			break
 		}
 		home, err := os.UserHomeDir()
 		if err != nil {
 			return "", err
 		}
-		baseDir = filepath.Join(home, ".local", "share", appName, servers)
+		dataDir = filepath.Join(home, ".local", "share", appName)
 	case windows:
 		local := os.Getenv("LOCALAPPDATA")
 		if local == "" {
 			return "", errors.New("LOCALAPPDATA is not defined")
 		}
-		baseDir = filepath.Join(local, appName, servers)
+		dataDir = filepath.Join(local, appName)
 	default:
 		return "", fmt.Errorf("unsupported operating system: %v", runtime.GOOS)
 	}
-	return filepath.Abs(baseDir)
+	return filepath.Abs(dataDir)
+}
+
+func getBaseDir() (string, error) {
+	dataDir, err := getDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, servers), nil
 }
 
-func New(baseDir string) (*App, error) {
+// getCacheDir returns the absolute path of the download cache, which defaults to the one in the data directory
+// even if the servers are installed into another directory.
+// The cache is in the lsm directory under the configured directory, so that it never takes over a directory of the user.
+func getCacheDir(dir string) (string, error) {
+	dir, err := expandHome(dir)
+	if err != nil {
+		return "", err
+	}
+	if dir == "" {
+		dataDir, err := getDataDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dataDir, cacheDirName), nil
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// New creates App with the options.
+func New(opts Options) (*App, error) {
+	baseDir, err := expandHome(opts.BaseDir)
+	if err != nil {
+		return nil, err
+	}
 	if baseDir == "" {
 		p, err := getBaseDir()
 		if err != nil {
@@ -75,40 +129,148 @@ func New(baseDir string) (*App, error) {
 		baseDir = p
 	}
 	installers := map[string]Installer{
-		"eclipse.jdt.ls":                    NewEclipseJDTLSInstaller(baseDir),
-		"efm-langserver":                    NewEfmLSInstaller(baseDir),
-		"gopls":                             NewGoInstaller(baseDir, "golang.org/x/tools/gopls", "gopls", false),
-		"sqls":                              NewGoInstaller(baseDir, "github.com/lighttiger2505/sqls", "sqls", true),
-		"kotlin-language-server":            NewKotlinLSInstaller(baseDir),
-		"metals":                            NewMetalsInstaller(baseDir),
-		"bash-language-server":              NewNpmInstaller(baseDir, "bash-language-server", "bash-language-server"),
-		"dockerfile-language-server-nodejs": NewNpmInstaller(baseDir, "dockerfile-language-server-nodejs", "docker-langserver"),
-		"graphql-lsp":                       NewNpmInstaller(baseDir, "graphql-language-service-cli", "graphql-lsp"),
-		"purescript-language-server":        NewNpmInstaller(baseDir, "purescript-language-server", "purescript-language-server"),
-		"svelte-language-server":            NewNpmInstaller(baseDir, "svelte-language-server", "svelteserver"),
-		"typescript-language-server":        NewNpmInstaller(baseDir, "typescript-language-server", "typescript-language-server"),
-		"vim-language-server":               NewNpmInstaller(baseDir, "vim-language-server", "vim-language-server"),
-		"vls":                               NewNpmInstaller(baseDir, "vls", "vls"),
-		"vscode-css-languageserver":         NewNpmInstaller(baseDir, "vscode-css-languageserver-bin", "css-languageserver"),
-		"vscode-html-languageserver":        NewNpmInstaller(baseDir, "vscode-html-languageserver-bin", "html-languageserver"),
-		"vscode-json-languageserver":        NewNpmInstaller(baseDir, "vscode-json-languageserver", "vscode-json-languageserver"),
-		"yaml-language-server":              NewNpmInstaller(baseDir, "yaml-language-server", "yaml-language-server"),
-		"cmake-language-server":             NewPipInstaller(baseDir, "cmake-language-server", "cmake-language-server"),
-		"fortran-language-server":           NewPipInstaller(baseDir, "fortran-language-server", "fortls"),
-		"python-language-server":            NewPipInstaller(baseDir, "python-language-server", "pyls"),
-		"rust-analyzer":                     NewRustAnalyzerInstaller(baseDir),
-		"terraform-ls":                      NewTerraformLSInstaller(baseDir),
-		"terraform-lsp":                     NewTerraformLSPInstaller(baseDir),
-		"eslint-server":                     NewVSCodeExtensionInstaller(baseDir, "eslint-server", "vscode-eslint", "https://github.com/microsoft/vscode-eslint/releases/download/release%2F2.1.4-next.1/vscode-eslint-2.1.4.vsix"),
-		"lemminx":                           NewVSCodeExtensionInstaller(baseDir, "lemminx", "vscode-xml", "https://github.com/redhat-developer/vscode-xml/releases/download/0.11.0/redhat.vscode-xml-0.11.0.vsix"),
-		"reason-language-server":            NewVSCodeExtensionInstaller(baseDir, "reason-language-server", "reason-vscode", "https://github.com/jaredly/reason-language-server/releases/download/1.7.8/reason-vscode-1.7.8.vsix"),
-	}
-
-	return &App{
+		"eclipse.jdt.ls": NewEclipseJDTLSInstaller(baseDir),
+		"metals":         NewMetalsInstaller(baseDir),
+	}
+
+	cacheDir, err := getCacheDir(opts.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	// the module cache is not cleaned with the download cache, and is in the install directory if no data directory
+	var modCache string
+	if dataDir, err := getDataDir(); err == nil {
+		modCache = filepath.Join(dataDir, "gomod")
+	}
+	client, err := newHTTPClient(opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	a := &App{
 		baseDir:    baseDir,
 		installers: installers,
+		in:         os.Stdin,
 		out:        os.Stdout,
-	}, nil
+		errOut:     os.Stderr,
+		client:     client,
+		endpoints:  DefaultEndpoints,
+		cache:      newDownloadCache(cacheDir),
+		modCache:   modCache,
+		output:     opts.Output,
+		jobs:       opts.Jobs,
+		proxy:      opts.Proxy,
+		retry:      newRetryPolicy(opts.Retries, opts.Timeout),
+	}
+	if a.output == ListStyleUndefined {
+		a.output = ListStyleTable
+	}
+	if a.jobs < 1 {
+		a.jobs = defaultJobs
+	}
+	r, err := parseRegistry(builtinRegistry, ".yaml")
+	if err != nil {
+		return nil, err
+	}
+	if err := a.mergeRegistry(r); err != nil {
+		return nil, err
+	}
+	for _, path := range opts.Registries {
+		if path, err = expandHome(path); err != nil {
+			return nil, err
+		}
+		if err := a.LoadRegistry(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.applyOptions(opts.Servers); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// legacyDirs are the directories of language servers installed by older versions, which were named after the npm package.
+var legacyDirs = map[string]string{
+	"graphql-lsp":                "graphql-language-service-cli",
+	"vscode-css-languageserver":  "vscode-css-languageserver-bin",
+	"vscode-html-languageserver": "vscode-html-languageserver-bin",
+}
+
+// MigrateLegacyDirs renames the legacy directories to the ones named after the language servers.
+// A legacy directory is left as is if the language server is already installed in the new one.
+// It is called by the commands that install language servers, so that the others never change the base directory.
+func (a *App) MigrateLegacyDirs() error {
+	for name, old := range legacyDirs {
+		i, ok := a.installers[name]
+		if !ok {
+			continue
+		}
+		from := filepath.Join(a.baseDir, old)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if _, err := os.Stat(i.Dir()); err == nil {
+			continue
+		}
+		if err := os.Rename(from, i.Dir()); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", from, i.Dir(), err)
+		}
+		log.Printf("moved %s to %s", from, i.Dir())
+	}
+	return nil
+}
+
+// LoadRegistry loads a registry file and merges its entries into the installers.
+// Entries override built-in installers with the same name.
+func (a *App) LoadRegistry(path string) error {
+	r, err := loadRegistryFile(path)
+	if err != nil {
+		return err
+	}
+	return a.mergeRegistry(r)
+}
+
+// SetInsecureSkipVerify disables the checksum verification of downloaded files.
+func (a *App) SetInsecureSkipVerify(skip bool) {
+	a.insecureSkipVerify = skip
+}
+
+// SetPlatform sets the platform to install language servers for instead of the host, e.g. "linux" and "arm64".
+// An empty os or arch is the one of the host.
+// Only language servers downloaded as a single artifact per platform can be installed for another platform.
+func (a *App) SetPlatform(os, arch string) {
+	a.platform = hostPlatform()
+	if os != "" {
+		a.platform.os = os
+	}
+	if arch != "" {
+		a.platform.arch = arch
+	}
+}
+
+// SetEndpoints sets the registries used to look up the latest versions.
+func (a *App) SetEndpoints(e Endpoints) {
+	a.endpoints = e
+}
+
+func (a *App) mergeRegistry(r *Registry) error {
+	for _, e := range r.Servers {
+		if e.Kind == "" {
+			i, err := a.getInstaller(e.Name)
+			if err != nil {
+				return err
+			}
+			if err := e.apply(i); err != nil {
+				return err
+			}
+			continue
+		}
+		i, err := e.newInstaller(a.baseDir)
+		if err != nil {
+			return err
+		}
+		a.installers[e.Name] = i
+	}
+	return nil
 }
 
 func (a *App) getInstaller(name string) (Installer, error) {
@@ -124,42 +286,115 @@ func isSupported(i Installer) error {
 	if len(ss) == 0 {
 		return nil
 	}
+	p := i.base().platform()
 	for _, s := range ss {
-		if runtime.GOARCH == s.arch && runtime.GOOS == s.os {
+		if p == s {
 			return nil
 		}
 	}
-	return fmt.Errorf("installer does not supports %s on %s %s", i.Name(), runtime.GOOS, runtime.GOARCH)
+	return fmt.Errorf("installer does not supports %s on %s %s", i.Name(), p.os, p.arch)
 }
 
-func (a *App) Install(ctx context.Context, name string) error {
-	i, err := a.getInstaller(name)
-	if err != nil {
-		return err
+// setTarget sets the platform of the installer, which must download an artifact if the platform is not the host.
+func (a *App) setTarget(i Installer) error {
+	if a.platform != (Support{}) && a.platform != hostPlatform() {
+		if _, ok := i.(artifacter); !ok {
+			return fmt.Errorf("%s cannot be installed for %s/%s, which is not the host platform", i.Name(), a.platform.os, a.platform.arch)
+		}
 	}
+	i.base().target = a.platform
+	return nil
+}
 
+// checkInstallable checks the platform and the prerequisites of the installer.
+func checkInstallable(ctx context.Context, i Installer) error {
 	if err := isSupported(i); err != nil {
 		return err
 	}
 	for _, r := range i.Requires() {
 		if _, err := exec.LookPath(r); err != nil {
-			return err
+			return fmt.Errorf("%s requires %s: %w", i.Name(), r, err)
 		}
 	}
 	if err := i.RequireHook(ctx); err != nil {
-		return err
+		return fmt.Errorf("%s: %w", i.Name(), err)
 	}
+	return nil
+}
 
-	if err := os.RemoveAll(i.Dir()); err != nil {
-		return err
+// splitSpec splits "name@version" into name and version.
+func splitSpec(spec string) (name, version string) {
+	if n := strings.LastIndex(spec, "@"); n > 0 {
+		return spec[:n], spec[n+1:]
 	}
-	if err := os.MkdirAll(i.Dir(), 0777); err != nil {
-		return err
+	return spec, versionUnSpecified
+}
+
+// Install installs the language server specified as "name" or "name@version".
+func (a *App) Install(ctx context.Context, spec string) error {
+	_, err := a.install(ctx, spec)
+	return err
+}
+
+func (a *App) install(ctx context.Context, spec string) (*Receipt, error) {
+	name, version := splitSpec(spec)
+	i, err := a.getInstaller(name)
+	if err != nil {
+		return nil, err
 	}
-	if err := i.Install(ctx); err != nil {
-		return err
+	// artifacts for other platforms are only fetched, so that the installation for the host is kept
+	if p := a.platform; p != (Support{}) && p != hostPlatform() {
+		return nil, fmt.Errorf("%s cannot be installed for %s/%s, which is not the host platform; use cache fetch or bundle create to fetch it instead", name, p.os, p.arch)
+	}
+	if err := a.prepare(i); err != nil {
+		return nil, err
+	}
+	if a.lock != nil {
+		if a.insecureSkipVerify {
+			return nil, errors.New("frozen install cannot skip checksum verification")
+		}
+		if err := a.lock.pin(i, version); err != nil {
+			return nil, err
+		}
+	} else if version != versionUnSpecified {
+		if err := pinVersion(i, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkInstallable(ctx, i); err != nil {
+		return nil, err
+	}
+
+	r, err := installStaged(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	i.base().logger().Printf("%s %s installed into %s", name, r.Version, i.Dir())
+	return r, nil
+}
+
+// pinVersion sets the requested version to the installer.
+// It fails if the artifact URL does not depend on the version, since the artifact would be installed as the requested version whatever it is.
+func pinVersion(i Installer, version string) error {
+	art, ok := i.(artifacter)
+	if !ok || sameVersion(version, i.Version()) {
+		i.SetVersion(version)
+		return nil
+	}
+	b := i.base()
+	p := b.platform()
+	before, err := art.artifactURL(p)
+	prev := b.version
+	i.SetVersion(version)
+	if err != nil {
+		// the URL cannot be rendered without a version to compare with
+		return nil
+	}
+	if after, err := art.artifactURL(p); err == nil && after == before {
+		b.version = prev
+		return fmt.Errorf("%s cannot be pinned to %s since its download URL does not depend on the version: %s", i.Name(), version, after)
 	}
-	log.Printf("%s %s installed into %s", name, i.Version(), i.Dir())
 	return nil
 }
 
@@ -168,10 +403,14 @@ func (a *App) Uninstall(ctx context.Context, name string) error {
 	if err != nil {
 		return err
 	}
+	version := i.Version()
+	if r, err := readReceipt(i.Dir()); err == nil {
+		version = r.Version
+	}
 	if err := os.RemoveAll(i.Dir()); err != nil {
 		return err
 	}
-	log.Printf("%s uninstalled from %s", name, i.Dir())
+	log.Printf("%s %s uninstalled from %s", name, version, i.Dir())
 	return nil
 }
 
@@ -187,34 +426,27 @@ func (a *App) List(ctx context.Context, style ListStyle) error {
 	if err := os.MkdirAll(a.baseDir, 0777); err != nil {
 		return err
 	}
-	dirs, err := ioutil.ReadDir(a.baseDir)
-	if err != nil {
-		return err
-	}
 	list := make([]languageServer, 0, len(a.installers))
 	for _, i := range a.installers {
-		installed := false
-		for _, d := range dirs {
-			if d.IsDir() && d.Name() == i.Name() {
-				bin := filepath.Join(a.baseDir, i.Name(), i.BinName())
-				info, err := os.Stat(bin)
-				if err != nil {
-					installed = false
-					continue
-				}
-				if isExecutable(info.Mode()) {
-					installed = true
-					break
-				}
+		installed := isInstalled(i)
+		version := i.Version()
+		if installed {
+			if r, err := readReceipt(i.Dir()); err == nil {
+				version = r.Version
 			}
 		}
 		list = append(list, languageServer{
 			Name:      i.Name(),
-			Version:   i.Version(),
+			Version:   version,
 			Installed: installed,
 		})
 	}
-	switch style {
+	return a.render(list, style)
+}
+
+// render renders a slice of structs in the style.
+func (a *App) render(list interface{}, style ListStyle) error {
+	switch a.listStyle(style) {
 	case ListStyleJSON:
 		return a.renderJSON(list)
 	case ListStyleTable, ListStyleUndefined:
@@ -224,7 +456,7 @@ func (a *App) List(ctx context.Context, style ListStyle) error {
 	}
 }
 
-func (a *App) renderJSON(list []languageServer) error {
+func (a *App) renderJSON(list interface{}) error {
 	b, err := json.MarshalIndent(list, "", strings.Repeat(" ", 2))
 	if err != nil {
 		return err
@@ -235,21 +467,21 @@ func (a *App) renderJSON(list []languageServer) error {
 	return nil
 }
 
-func (a *App) renderTable(list []languageServer) error {
+func (a *App) renderTable(list interface{}) error {
 	table := tablewriter.NewWriter(a.out)
-	t := reflect.TypeOf(languageServer{})
+	v := reflect.ValueOf(list)
+	t := v.Type().Elem()
 	headers := make([]string, 0, t.NumField())
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
 		headers = append(headers, f.Name)
 	}
 	table.SetHeader(headers)
-	for _, item := range list {
-		v := reflect.ValueOf(item)
-		t := reflect.TypeOf(item)
+	for n := 0; n < v.Len(); n++ {
+		item := v.Index(n)
 		rows := make([]string, 0, t.NumField())
 		for i := 0; i < t.NumField(); i++ {
-			rows = append(rows, fmt.Sprint(v.Field(i)))
+			rows = append(rows, fmt.Sprint(item.Field(i)))
 		}
 		table.Append(rows)
 	}
@@ -257,6 +489,14 @@ func (a *App) renderTable(list []languageServer) error {
 	return nil
 }
 
+func isInstalled(i Installer) bool {
+	info, err := os.Stat(filepath.Join(i.Dir(), i.BinName()))
+	if err != nil {
+		return false
+	}
+	return isExecutable(info.Mode())
+}
+
 func isExecutable(mode os.FileMode) bool {
 	// FIXME
 	if isWindows {
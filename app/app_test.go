@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -11,18 +12,58 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNew_unix_home(t *testing.T) {
-	if isWindows {
-		t.Skip()
+func TestMain(m *testing.M) {
+	// the download cache defaults to the data directory, which must not be the one of the user
+	dir, err := ioutil.TempDir("", "lsm-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_DATA_HOME", dir)
+	os.Setenv("LOCALAPPDATA", dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newRegistryTestApp returns an app with the registry merged and its foo-ls installer, whose output is discarded.
+func newRegistryTestApp(t *testing.T, registry string) (*App, Installer) {
+	t.Helper()
+	return newRegistryTestAppWithOptions(t, Options{}, registry)
+}
+
+// newRegistryTestAppWithOptions is newRegistryTestApp with the options, whose BaseDir defaults to a temporary directory.
+func newRegistryTestAppWithOptions(t *testing.T, opts Options, registry string) (*App, Installer) {
+	t.Helper()
+	if opts.BaseDir == "" {
+		opts.BaseDir = t.TempDir()
 	}
-	p, err := filepath.Abs("./testdata")
+	a, err := New(opts)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.Setenv("HOME", p); err != nil {
+	r, err := parseRegistry([]byte(registry), ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.mergeRegistry(r); err != nil {
+		t.Fatal(err)
+	}
+	i, err := a.getInstaller("foo-ls")
+	if err != nil {
 		t.Fatal(err)
 	}
-	a, err := New("")
+	i.SetWriter(ioutil.Discard)
+	return a, i
+}
+
+func TestNew_unix_home(t *testing.T) {
+	if isWindows {
+		t.Skip()
+	}
+	p := t.TempDir()
+	t.Setenv("HOME", p)
+	t.Setenv("XDG_DATA_HOME", "")
+	a, err := New(Options{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -33,25 +74,35 @@ func TestNew_xdgDataHome(t *testing.T) {
 	if isWindows {
 		t.Skip()
 	}
-	p, err := filepath.Abs("./testdata")
+	p := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", p)
+	a, err := New(Options{})
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := os.Setenv("XDG_DATA_HOME", p); err != nil {
+	assert.Equal(t, filepath.Join(p, "lsm", "servers"), a.baseDir)
+	assert.Equal(t, filepath.Join(p, "lsm", "cache"), a.cache.dir)
+
+	// the cache stays in the data directory with another base dir
+	a, err = New(Options{BaseDir: filepath.Join(t.TempDir(), "servers")})
+	if err != nil {
 		t.Fatal(err)
 	}
-	a, err := New("")
+	assert.Equal(t, filepath.Join(p, "lsm", "cache"), a.cache.dir)
+
+	cacheDir := t.TempDir()
+	a, err = New(Options{CacheDir: cacheDir})
 	if err != nil {
 		t.Fatal(err)
 	}
-	assert.Equal(t, filepath.Join(p, "lsm", "servers"), a.baseDir)
+	assert.Equal(t, filepath.Join(cacheDir, "lsm"), a.cache.dir)
 }
 
 func TestNew_windows(t *testing.T) {
 	if !isWindows {
 		t.Skip()
 	}
-	a, err := New("")
+	a, err := New(Options{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -59,12 +110,43 @@ func TestNew_windows(t *testing.T) {
 	assert.Equal(t, filepath.Join(local, "lsm", "servers"), a.baseDir)
 }
 
+func TestApp_MigrateLegacyDirs(t *testing.T) {
+	baseDir := t.TempDir()
+	legacy := filepath.Join(baseDir, "vscode-css-languageserver-bin")
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(legacy, "package.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// already installed in the new directory
+	for _, dir := range []string{"vscode-html-languageserver-bin", "vscode-html-languageserver"} {
+		if err := os.MkdirAll(filepath.Join(baseDir, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := New(Options{BaseDir: baseDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// New never changes the base directory, e.g. for read-only commands
+	assert.DirExists(t, legacy)
+
+	if err := a.MigrateLegacyDirs(); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoDirExists(t, legacy)
+	assert.FileExists(t, filepath.Join(baseDir, "vscode-css-languageserver", "package.json"))
+	assert.DirExists(t, filepath.Join(baseDir, "vscode-html-languageserver-bin"))
+}
+
 func TestApp_List(t *testing.T) {
 	baseDir := filepath.Clean("./testdata/lsm/servers")
 
 	t.Run("not installed any language servers", func(t *testing.T) {
 		_ = os.RemoveAll(baseDir)
-		a, err := New(baseDir)
+		a, err := New(Options{BaseDir: baseDir})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -88,7 +170,7 @@ func TestApp_List(t *testing.T) {
 		}
 		const efmls = "efm-langserver"
 		_ = os.RemoveAll(baseDir)
-		a, err := New(baseDir)
+		a, err := New(Options{BaseDir: baseDir})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -118,7 +200,7 @@ func TestApp_List(t *testing.T) {
 
 	t.Run("table", func(t *testing.T) {
 		_ = os.RemoveAll(baseDir)
-		a, err := New(baseDir)
+		a, err := New(Options{BaseDir: baseDir})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -129,3 +211,47 @@ func TestApp_List(t *testing.T) {
 		}
 	})
 }
+
+func Test_splitSpec(t *testing.T) {
+	tests := []struct {
+		spec, name, version string
+	}{
+		{"gopls", "gopls", ""},
+		{"gopls@v0.9.1", "gopls", "v0.9.1"},
+		{"eclipse.jdt.ls@1.14.0-202207211651", "eclipse.jdt.ls", "1.14.0-202207211651"},
+		{"@scope/foo@1.0.0", "@scope/foo", "1.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			name, version := splitSpec(tt.spec)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
+
+func TestInstaller_SetVersion(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, version, want string
+	}{
+		{"efm-langserver", "", "0.0.44"},
+		{"efm-langserver", "v0.0.14", "0.0.14"},
+		{"terraform-ls", "", "0.29.0"},
+		{"terraform-ls", "0.2.0", "0.2.0"},
+		{"rust-analyzer", "2022-08-08", "2022-08-08"},
+		{"gopls", "v0.9.1", "v0.9.1"},
+		{"typescript-language-server", "1.0.0", "1.0.0"},
+	}
+	for _, tt := range tests {
+		i, err := a.getInstaller(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i.SetVersion(tt.version)
+		assert.Equal(t, tt.want, i.Version(), tt.name)
+	}
+}
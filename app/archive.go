@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/mholt/archiver/v3"
+)
+
+// ArchiveInstaller downloads a file from a URL template.
+// Archives are extracted into Dir, other files are saved as the binary itself.
+// bin is the path of the executable relative to Dir, and a symlink named after its base name is created.
+type ArchiveInstaller struct {
+	baseInstaller
+
+	name, url, bin string
+	aliases        Aliases
+}
+
+var _ Installer = (*ArchiveInstaller)(nil)
+
+func NewArchiveInstaller(baseDir, name, url, bin string) *ArchiveInstaller {
+	return &ArchiveInstaller{
+		baseInstaller: newBaseInstaller(filepath.Join(baseDir, name)),
+		name:          name,
+		url:           url,
+		bin:           bin,
+	}
+}
+
+func (i *ArchiveInstaller) Name() string {
+	return i.name
+}
+
+func (i *ArchiveInstaller) BinName() string {
+	if i.bin == "" {
+		return noExecutable
+	}
+	bin, err := renderTemplate(i.bin, i.data(i.platform()))
+	if err != nil {
+		return noExecutable
+	}
+	return path.Base(bin)
+}
+
+func (i *ArchiveInstaller) Requires() []string {
+	return noRequires
+}
+
+func (i *ArchiveInstaller) Kind() string {
+	return kindArchive
+}
+
+// data returns templateData for the platform with the aliases applied.
+func (i *ArchiveInstaller) data(s Support) templateData {
+	return i.templateData(i.Name()).withAliases(s, i.aliases)
+}
+
+func (i *ArchiveInstaller) setURL(u string) {
+	i.url = u
+}
+
+func (i *ArchiveInstaller) artifactURL(s Support) (string, error) {
+	return renderTemplate(i.url, i.data(s))
+}
+
+func (i *ArchiveInstaller) Install(ctx context.Context, dir string) error {
+	u, err := i.artifactURL(i.platform())
+	if err != nil {
+		return err
+	}
+	var bin string
+	if i.bin != "" {
+		if bin, err = renderTemplate(i.bin, i.data(i.platform())); err != nil {
+			return err
+		}
+	}
+	return i.installArtifact(ctx, dir, u, bin, i.BinName())
+}
+
+// installArtifact downloads u into dir.
+// An archive is extracted, and bin in it is made executable and linked as link if bin is in a subdirectory.
+// Any other file is saved as link.
+func (i *baseInstaller) installArtifact(ctx context.Context, dir, u, bin, link string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	file := path.Base(parsed.Path)
+
+	if _, err := archiver.ByExtension(file); err == nil {
+		if err := i.FetchWithExtract(ctx, u, filepath.Join(dir, file)); err != nil {
+			return err
+		}
+		if bin == "" {
+			return nil
+		}
+		if err := os.Chmod(filepath.Join(dir, bin), 0777); err != nil {
+			return err
+		}
+		if filepath.Base(bin) == bin && bin == link {
+			return nil
+		}
+		return os.Symlink(filepath.FromSlash(bin), filepath.Join(dir, link))
+	}
+
+	if link == noExecutable {
+		return fmt.Errorf("bin is required to save %s", file)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, link)
+	if err := i.Download(req, dst); err != nil {
+		return err
+	}
+	return os.Chmod(dst, 0777)
+}
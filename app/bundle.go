@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/mholt/archiver/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const bundleManifestFile = "bundle.yaml"
+
+// BundleManifest describes the language servers packaged in a bundle.
+// The artifacts are stored in a download cache next to it, keyed by their URLs.
+type BundleManifest struct {
+	OS         string         `yaml:"os"`
+	Arch       string         `yaml:"arch"`
+	LSMVersion string         `yaml:"lsm_version"`
+	Servers    []LockedServer `yaml:"servers"`
+}
+
+func readBundleManifest(dir string) (*BundleManifest, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, bundleManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m BundleManifest
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", bundleManifestFile, err)
+	}
+	if m.OS == "" || m.Arch == "" {
+		return nil, fmt.Errorf("%s: os and arch are required", bundleManifestFile)
+	}
+	return &m, nil
+}
+
+// offlineTransport fails all requests, so that nothing is downloaded while installing from a bundle.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("%s is not in the bundle", req.URL)
+}
+
+// CreateBundle downloads the artifacts of the language servers specified as "name" or "name@version"
+// for the platform set by SetPlatform, and packages them with their versions and checksums into path.
+// The archive format is chosen by the extension of path, e.g. ".tar.zst".
+// Unspecified versions are resolved as Lock does.
+func (a *App) CreateBundle(ctx context.Context, specs []string, path string) error {
+	dir, err := ioutil.TempDir("", "lsm-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	cache := a.cache
+	a.cache = newDownloadCache(filepath.Join(dir, cacheDirName))
+	defer func() { a.cache = cache }()
+
+	p := a.platform
+	if p == (Support{}) {
+		p = hostPlatform()
+	}
+	m := &BundleManifest{OS: p.os, Arch: p.arch, LSMVersion: lsmVersion()}
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		name, version := splitSpec(spec)
+		if seen[name] {
+			return fmt.Errorf("%s is specified more than once", name)
+		}
+		seen[name] = true
+		i, err := a.getInstaller(name)
+		if err != nil {
+			return err
+		}
+		if version, err = a.resolveVersion(ctx, i, version); err != nil {
+			return err
+		}
+		e, err := a.fetch(ctx, name+"@"+version)
+		if err != nil {
+			return err
+		}
+		m.Servers = append(m.Servers, LockedServer{
+			Name:      name,
+			Version:   i.Version(),
+			Kind:      i.Kind(),
+			Artifacts: []LockedArtifact{{OS: p.os, Arch: p.arch, URL: e.URL, SHA256: normalizeDigest(e.Digest)}},
+		})
+	}
+
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+	manifest := filepath.Join(dir, bundleManifestFile)
+	if err := ioutil.WriteFile(manifest, b, 0666); err != nil {
+		return err
+	}
+	return archiver.Archive([]string{manifest, a.cache.dir}, path)
+}
+
+// InstallBundle installs the language servers packaged in the bundle created by CreateBundle without network access.
+// They are installed for the platform of the bundle, and the artifacts are verified against the bundled checksums.
+func (a *App) InstallBundle(ctx context.Context, path string, jobs int, style ListStyle) error {
+	if a.insecureSkipVerify {
+		return errors.New("bundle install cannot skip checksum verification")
+	}
+	dir, err := ioutil.TempDir("", "lsm-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := archiver.Unarchive(path, dir); err != nil {
+		return err
+	}
+	m, err := readBundleManifest(dir)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if p := hostPlatform(); m.OS != p.os || m.Arch != p.arch {
+		return fmt.Errorf("%s is a bundle for %s/%s, which is not the host platform", path, m.OS, m.Arch)
+	}
+
+	// the servers are pinned to the bundled URLs and checksums by the frozen lock, without changing the installers
+	specs := make([]string, 0, len(m.Servers))
+	for _, s := range m.Servers {
+		if _, err := a.getInstaller(s.Name); err != nil {
+			return err
+		}
+		spec := s.Name
+		if s.Version != versionUnSpecified {
+			spec += "@" + s.Version
+		}
+		specs = append(specs, spec)
+	}
+
+	cache, lock, client, retry, platform := a.cache, a.lock, a.client, a.retry, a.platform
+	a.cache = newDownloadCache(filepath.Join(dir, cacheDirName))
+	a.lock = &Lock{Servers: m.Servers}
+	a.client = &http.Client{Transport: offlineTransport{}}
+	a.retry = retryPolicy{}
+	defer func() { a.cache, a.lock, a.client, a.retry, a.platform = cache, lock, client, retry, platform }()
+	a.SetPlatform(m.OS, m.Arch)
+	return a.InstallAll(ctx, specs, jobs, style)
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mholt/archiver/v3"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApp_InstallBundle(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	gh, apiCalls := newGitHubTestServer(t, map[string][]byte{
+		"foo-ls_" + runtime.GOOS: []byte("#!/bin/sh\n"),
+	})
+	registry := `
+  - name: bar-ls
+    kind: archive
+    version: 0.1.0
+    url: ` + ts.URL + `/{{.Version}}/bar-ls
+    bin: bar-ls
+`
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.zst")
+
+	a, _ := newGitHubReleaseTestApp(t, gh, `
+    asset: foo-ls_{{.OS}}
+    bin: foo-ls`+registry)
+	if err := a.CreateBundle(context.Background(), []string{"foo-ls", "bar-ls@0.2.0"}, bundle); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	// the servers are installed from the bundle even though the GitHub API and the downloads are unavailable
+	a, foo := newGitHubReleaseTestApp(t, gh, `
+    asset: foo-ls_{{.OS}}
+    bin: foo-ls`+registry)
+	gh.Close()
+	a.out = ioutil.Discard
+	for _, name := range []string{"foo-ls", "bar-ls"} {
+		i, err := a.getInstaller(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i.SetWriter(ioutil.Discard)
+	}
+	if err := a.InstallBundle(context.Background(), bundle, 1, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"foo-ls": "1.2.0",
+		"bar-ls": "0.2.0",
+	}
+	for name, version := range tests {
+		i, err := a.getInstaller(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, isInstalled(i), name)
+		r, err := readReceipt(i.Dir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, version, r.Version)
+		assert.NotEmpty(t, r.Checksum)
+	}
+	assert.Zero(t, atomic.LoadInt32(apiCalls))
+
+	// the installers are not pinned to the bundle afterwards
+	assert.Nil(t, a.lock)
+	assert.Empty(t, foo.(*GitHubReleaseInstaller).url)
+}
+
+func TestApp_InstallBundle_tampered(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	body := "#!/bin/sh\n"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer ts.Close()
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 0.1.0
+    url: `+ts.URL+`/{{.Version}}/foo-ls
+    bin: foo-ls
+`)
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "bundle.tar.gz")
+	if err := a.CreateBundle(context.Background(), []string{"foo-ls"}, bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	// the bundled artifact no longer matches the checksum in the manifest
+	tmp := t.TempDir()
+	if err := archiver.Unarchive(bundle, tmp); err != nil {
+		t.Fatal(err)
+	}
+	m, err := readBundleManifest(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, runtime.GOOS, m.OS)
+	assert.Len(t, m.Servers, 1)
+	m.Servers[0].Artifacts[0].SHA256 = strings.Repeat("0", 64)
+	b, err := yaml.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, bundleManifestFile), b, 0666); err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(dir, "tampered.tar.gz")
+	if err := archiver.Archive([]string{filepath.Join(tmp, bundleManifestFile), filepath.Join(tmp, cacheDirName)}, tampered); err != nil {
+		t.Fatal(err)
+	}
+
+	a.out = ioutil.Discard
+	err = a.InstallBundle(context.Background(), tampered, 1, ListStyleJSON)
+	assert.Error(t, err)
+	assert.False(t, isInstalled(i))
+}
@@ -0,0 +1,376 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const cacheDirName = "cache"
+
+// downloadCache is a content-addressed store of downloaded files shared across installations.
+// Contents are stored under blobs by their SHA-256 digests, and the index maps URLs to the digests.
+type downloadCache struct {
+	dir string
+}
+
+// cacheEntry is an index entry of the download cache.
+type cacheEntry struct {
+	URL      string    `json:"url"`
+	Digest   string    `json:"digest"`
+	Size     int64     `json:"size"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+func newDownloadCache(dir string) *downloadCache {
+	return &downloadCache{dir: dir}
+}
+
+func (c *downloadCache) blobDir() string {
+	return filepath.Join(c.dir, "blobs", "sha256")
+}
+
+func (c *downloadCache) blobPath(digest string) string {
+	return filepath.Join(c.blobDir(), normalizeDigest(digest))
+}
+
+func (c *downloadCache) indexPath(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(c.dir, "index", hex.EncodeToString(sum[:])+".json")
+}
+
+// clean removes the blobs and the index, and the cache directory only if nothing else is in it.
+// Anything else in the directory is left as is since the directory may be shared, e.g. set by cache_dir.
+func (c *downloadCache) clean() error {
+	for _, d := range []string{"blobs", "index"} {
+		if err := os.RemoveAll(filepath.Join(c.dir, d)); err != nil {
+			return err
+		}
+	}
+	files, err := ioutil.ReadDir(c.dir)
+	if os.IsNotExist(err) || (err == nil && len(files) != 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(c.dir)
+}
+
+func (c *downloadCache) lookup(u string) (*cacheEntry, bool) {
+	b, err := ioutil.ReadFile(c.indexPath(u))
+	if err != nil {
+		return nil, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil || e.URL != u {
+		return nil, false
+	}
+	return &e, true
+}
+
+// get copies the cached contents of the URL to dst and returns their digest.
+// Corrupted contents are evicted and reported as a miss.
+func (c *downloadCache) get(u, dst string) (string, bool) {
+	e, ok := c.lookup(u)
+	if !ok {
+		return "", false
+	}
+	digest, err := c.copyBlob(e, dst)
+	if err != nil {
+		log.Printf("download cache: %v", err)
+		if err := c.remove(e); err != nil {
+			log.Println(err)
+		}
+		return "", false
+	}
+	return digest, true
+}
+
+func (c *downloadCache) copyBlob(e *cacheEntry, dst string) (string, error) {
+	src, err := os.Open(c.blobPath(e.Digest))
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	f, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), src); err != nil {
+		return "", err
+	}
+	digest := "sha256:" + hex.EncodeToString(h.Sum(nil))
+	if normalizeDigest(digest) != normalizeDigest(e.Digest) {
+		return "", fmt.Errorf("%s is corrupted", e.URL)
+	}
+	return digest, nil
+}
+
+// read returns the cached contents of the URL.
+func (c *downloadCache) read(u string) ([]byte, bool) {
+	e, ok := c.lookup(u)
+	if !ok {
+		return nil, false
+	}
+	b, err := ioutil.ReadFile(c.blobPath(e.Digest))
+	if err != nil {
+		return nil, false
+	}
+	sum := sha256.Sum256(b)
+	if hex.EncodeToString(sum[:]) != normalizeDigest(e.Digest) {
+		return nil, false
+	}
+	return b, true
+}
+
+// put stores the contents of r as the contents of the URL.
+func (c *downloadCache) put(u string, r io.Reader) error {
+	if err := os.MkdirAll(c.blobDir(), 0777); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.blobDir(), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	e := cacheEntry{
+		URL:      u,
+		Digest:   "sha256:" + hex.EncodeToString(h.Sum(nil)),
+		Size:     size,
+		CachedAt: time.Now().UTC(),
+	}
+	if err := os.Rename(tmp.Name(), c.blobPath(e.Digest)); err != nil {
+		return err
+	}
+	return c.writeIndex(&e)
+}
+
+func (c *downloadCache) putFile(u, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.put(u, f)
+}
+
+func (c *downloadCache) writeIndex(e *cacheEntry) error {
+	p := c.indexPath(e.URL)
+	if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(e, "", strings.Repeat(" ", 2))
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// evict removes the cached contents of the URL, if any.
+func (c *downloadCache) evict(u string) error {
+	e, ok := c.lookup(u)
+	if !ok {
+		return nil
+	}
+	return c.remove(e)
+}
+
+func (c *downloadCache) remove(e *cacheEntry) error {
+	if err := os.Remove(c.indexPath(e.URL)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(c.blobPath(e.Digest)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// entries returns the index entries sorted by URL.
+func (c *downloadCache) entries() ([]cacheEntry, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "index", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]cacheEntry, 0, len(files))
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var e cacheEntry
+		if err := json.Unmarshal(b, &e); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(x, y int) bool {
+		return list[x].URL < list[y].URL
+	})
+	return list, nil
+}
+
+// prune removes the entries whose digests are not kept, and the blobs no entry refers to.
+func (c *downloadCache) prune(keep map[string]bool) ([]cacheEntry, error) {
+	list, err := c.entries()
+	if err != nil {
+		return nil, err
+	}
+	removed := make([]cacheEntry, 0, len(list))
+	referred := make(map[string]bool, len(list))
+	for n := range list {
+		e := &list[n]
+		if keep[normalizeDigest(e.Digest)] {
+			referred[normalizeDigest(e.Digest)] = true
+			continue
+		}
+		if err := os.Remove(c.indexPath(e.URL)); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		removed = append(removed, *e)
+	}
+	blobs, err := filepath.Glob(filepath.Join(c.blobDir(), "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range blobs {
+		if name := filepath.Base(b); referred[name] || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if err := os.Remove(b); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+// ListCache shows the downloads in the cache.
+func (a *App) ListCache(ctx context.Context, style ListStyle) error {
+	list, err := a.cache.entries()
+	if err != nil {
+		return err
+	}
+	return a.render(list, style)
+}
+
+// CleanCache removes all the downloads in the cache.
+func (a *App) CleanCache(ctx context.Context) error {
+	return a.cache.clean()
+}
+
+// PruneCache removes the downloads that no installed language server refers to, and shows them.
+func (a *App) PruneCache(ctx context.Context, style ListStyle) error {
+	keep := make(map[string]bool)
+	for _, i := range a.installers {
+		if r, err := readReceipt(i.Dir()); err == nil && r.Checksum != "" {
+			keep[normalizeDigest(r.Checksum)] = true
+		}
+	}
+	removed, err := a.cache.prune(keep)
+	if err != nil {
+		return err
+	}
+	return a.render(removed, style)
+}
+
+// FetchCache downloads the artifacts of the language servers specified as "name" or "name@version" into the cache
+// without installing them, for the platform set by SetPlatform, and shows them.
+func (a *App) FetchCache(ctx context.Context, specs []string, style ListStyle) error {
+	fetched := make([]cacheEntry, 0, len(specs))
+	for _, spec := range specs {
+		e, err := a.fetch(ctx, spec)
+		if err != nil {
+			return err
+		}
+		fetched = append(fetched, *e)
+	}
+	return a.render(fetched, style)
+}
+
+// volatileArtifacter is implemented by installers whose artifact URL serves changing contents for some versions.
+type volatileArtifacter interface {
+	volatile() bool
+}
+
+func (a *App) fetch(ctx context.Context, spec string) (*cacheEntry, error) {
+	name, version := splitSpec(spec)
+	i, err := a.getInstaller(name)
+	if err != nil {
+		return nil, err
+	}
+	art, ok := i.(artifacter)
+	if !ok {
+		return nil, fmt.Errorf("%s has no artifact to fetch", name)
+	}
+	if version != versionUnSpecified {
+		if err := pinVersion(i, version); err != nil {
+			return nil, err
+		}
+	}
+	b := i.base()
+	if err := a.prepare(i); err != nil {
+		return nil, err
+	}
+	if err := isSupported(i); err != nil {
+		return nil, err
+	}
+	if v, ok := i.(volatileArtifacter); ok {
+		b.noCache = v.volatile()
+	}
+	if b.noCache {
+		return nil, fmt.Errorf("%s@%s is not cacheable", name, i.Version())
+	}
+	u, err := art.artifactURL(b.platform())
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempDir("", "lsm-fetch-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	file, err := urlFileName(u)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	b.data = newTemplateData(i)
+	if err := b.Download(req, filepath.Join(tmp, file)); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	e, ok := a.cache.lookup(u)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is not cached", name, u)
+	}
+	return e, nil
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Install_cache(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte("#!/bin/sh\n# " + r.URL.Path + "\n"))
+	}))
+	defer ts.Close()
+
+	a, i := newRegistryTestAppWithOptions(t, Options{CacheDir: t.TempDir()}, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 1.0.0
+    url: `+ts.URL+`/{{.Version}}/foo-ls
+    bin: foo-ls
+`)
+	ctx := context.Background()
+
+	install := func(t *testing.T, spec string) {
+		t.Helper()
+		if err := a.Install(ctx, spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	install(t, "foo-ls@1.0.0")
+	install(t, "foo-ls@1.1.0")
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+
+	// switching back to the previous version is served from the cache
+	install(t, "foo-ls@1.0.0")
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+
+	// corrupted contents are downloaded again
+	e, ok := a.cache.lookup(ts.URL + "/1.1.0/foo-ls")
+	if !ok {
+		t.Fatal("1.1.0 is not cached")
+	}
+	if err := ioutil.WriteFile(a.cache.blobPath(e.Digest), []byte("broken"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	install(t, "foo-ls@1.1.0")
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+	// offline
+	ts.Close()
+	install(t, "foo-ls@1.0.0")
+	receipt, err := readReceipt(i.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ts.URL+"/1.0.0/foo-ls", receipt.Source)
+
+	listCache := func(t *testing.T) []cacheEntry {
+		t.Helper()
+		var buf bytes.Buffer
+		a.out = &buf
+		if err := a.ListCache(ctx, ListStyleJSON); err != nil {
+			t.Fatal(err)
+		}
+		var list []cacheEntry
+		if err := json.NewDecoder(&buf).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+	list := listCache(t)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, ts.URL+"/1.0.0/foo-ls", list[0].URL)
+		assert.Equal(t, receipt.Checksum, list[0].Digest)
+		assert.Equal(t, ts.URL+"/1.1.0/foo-ls", list[1].URL)
+	}
+
+	// prune keeps the installed 1.0.0 only
+	a.out = ioutil.Discard
+	if err := a.PruneCache(ctx, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	list = listCache(t)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, ts.URL+"/1.0.0/foo-ls", list[0].URL)
+	}
+	blobs, err := filepath.Glob(filepath.Join(a.cache.blobDir(), "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, blobs, 1)
+
+	if err := a.CleanCache(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Stat(a.cache.dir)
+	assert.True(t, os.IsNotExist(err))
+
+	// files that are not of the cache are kept
+	if err := a.cache.put(ts.URL+"/1.0.0/foo-ls", strings.NewReader("#!/bin/sh\n")); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(a.cache.dir, "notes.txt")
+	if err := ioutil.WriteFile(other, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.CleanCache(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assert.FileExists(t, other)
+	assert.NoDirExists(t, filepath.Join(a.cache.dir, "blobs"))
+	assert.NoDirExists(t, filepath.Join(a.cache.dir, "index"))
+}
+
+func TestApp_FetchCache(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#!/bin/sh\n# " + r.URL.Path + "\n"))
+	}))
+	defer ts.Close()
+
+	a, i := newRegistryTestAppWithOptions(t, Options{CacheDir: t.TempDir()}, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 1.0.0
+    url: `+ts.URL+`/{{.Version}}/{{.OS}}/{{.Arch}}/foo-ls{{.Exe}}
+    bin: foo-ls{{.Exe}}
+`)
+
+	var buf bytes.Buffer
+	a.out = &buf
+	a.SetPlatform(windows, arm64)
+	if err := a.FetchCache(context.Background(), []string{"foo-ls@1.1.0"}, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var fetched []cacheEntry
+	if err := json.NewDecoder(&buf).Decode(&fetched); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, fetched, 1) {
+		assert.Equal(t, ts.URL+"/1.1.0/windows/arm64/foo-ls.exe", fetched[0].URL)
+	}
+	_, ok := a.cache.lookup(ts.URL + "/1.1.0/windows/arm64/foo-ls.exe")
+	assert.True(t, ok)
+	assert.False(t, isInstalled(i))
+
+	assert.EqualError(t, a.FetchCache(context.Background(), []string{"gopls"}, ListStyleJSON), "gopls has no artifact to fetch")
+}
+
+func TestBaseInstaller_Download_cacheMismatch(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	b := newRetryTestInstaller(t)
+	b.cache = newDownloadCache(t.TempDir())
+	sum := sha256.Sum256([]byte(content))
+	b.checksums.digests = map[string]string{"foo-ls": hex.EncodeToString(sum[:])}
+	// e.g. a build replaced under the same URL
+	if err := b.cache.put(ts.URL+"/foo-ls", strings.NewReader("stale")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := download(t, b, ts.URL+"/foo-ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, content, got)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+	e, ok := b.cache.lookup(ts.URL + "/foo-ls")
+	if assert.True(t, ok) {
+		assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), e.Digest)
+	}
+}
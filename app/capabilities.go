@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// capabilityRow shows the LSP features a language server advertises in its ServerCapabilities.
+type capabilityRow struct {
+	Name           string `json:"name"`
+	Completion     bool   `json:"completion"`
+	Hover          bool   `json:"hover"`
+	Definition     bool   `json:"definition"`
+	References     bool   `json:"references"`
+	Rename         bool   `json:"rename"`
+	Formatting     bool   `json:"formatting"`
+	SemanticTokens bool   `json:"semantic_tokens"`
+	InlayHints     bool   `json:"inlay_hints"`
+	CodeActions    bool   `json:"code_actions"`
+	Error          string `json:"error"`
+}
+
+func newCapabilityRow(r *checkResult) capabilityRow {
+	has := make(map[string]bool, len(r.Capabilities))
+	for _, c := range r.Capabilities {
+		has[c] = true
+	}
+	return capabilityRow{
+		Name:           r.Name,
+		Completion:     has["completionProvider"],
+		Hover:          has["hoverProvider"],
+		Definition:     has["definitionProvider"],
+		References:     has["referencesProvider"],
+		Rename:         has["renameProvider"],
+		Formatting:     has["documentFormattingProvider"] || has["documentRangeFormattingProvider"],
+		SemanticTokens: has["semanticTokensProvider"],
+		InlayHints:     has["inlayHintProvider"],
+		CodeActions:    has["codeActionProvider"],
+		Error:          r.Error,
+	}
+}
+
+// Capabilities initializes the installed language servers as Check does, and shows a matrix of the LSP features they advertise.
+// All the installed language servers are compared if no name is given.
+func (a *App) Capabilities(ctx context.Context, names []string, timeout time.Duration, style ListStyle) error {
+	results, err := a.checkAll(ctx, names, timeout)
+	if err != nil {
+		return err
+	}
+	rows := make([]capabilityRow, 0, len(results))
+	for n := range results {
+		rows = append(rows, newCapabilityRow(&results[n]))
+	}
+	if err := a.render(rows, style); err != nil {
+		return err
+	}
+	return checkFailures(results)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Capabilities(t *testing.T) {
+	a := newCheckTestApp(t, "ok")
+	var out bytes.Buffer
+	a.out = &out
+	if err := a.Capabilities(context.Background(), nil, 0, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var rows []capabilityRow
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []capabilityRow{{Name: "fake-ls", Hover: true, CodeActions: true}}, rows)
+
+	out.Reset()
+	if err := a.Capabilities(context.Background(), nil, 0, ListStyleTable); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, out.String(), "| fake-ls | false      | true  |")
+}
+
+func TestApp_Capabilities_failed(t *testing.T) {
+	a := newCheckTestApp(t, "crash")
+	var out bytes.Buffer
+	a.out = &out
+	err := a.Capabilities(context.Background(), []string{"fake-ls"}, time.Second, ListStyleJSON)
+	assert.EqualError(t, err, "1 of 1 checks failed")
+	var rows []capabilityRow
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, rows[0].Error, "node: No such file or directory")
+	assert.False(t, rows[0].Hover)
+}
@@ -0,0 +1,184 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Prebuilt is a release binary preferred to building from source.
+// URL and Bin are rendered as the ones of an archive entry with Aliases.
+type Prebuilt struct {
+	URL string `json:"url" yaml:"url"`
+	// Bin is the path of the executable in the archive, which defaults to the bin of the entry.
+	Bin      string   `json:"bin,omitempty" yaml:"bin,omitempty"`
+	Supports []string `json:"supports,omitempty" yaml:"supports,omitempty"`
+	Aliases  Aliases  `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// CargoInstaller builds a crate with cargo install.
+// If a prebuilt binary is declared for the platform, it is downloaded instead and cargo is the fallback.
+type CargoInstaller struct {
+	baseInstaller
+
+	name, crate, binName string
+	features             []string
+	prebuilt             *Prebuilt
+}
+
+var _ Installer = (*CargoInstaller)(nil)
+
+func NewCargoInstaller(baseDir, name, crate, binName string, features []string, prebuilt *Prebuilt) *CargoInstaller {
+	i := &CargoInstaller{
+		name:     name,
+		crate:    crate,
+		binName:  binName,
+		features: features,
+		prebuilt: prebuilt,
+	}
+	i.baseInstaller = newBaseInstaller(filepath.Join(baseDir, i.Name()))
+	return i
+}
+
+func (i *CargoInstaller) Name() string {
+	return i.name
+}
+
+func (i *CargoInstaller) BinName() string {
+	if isWindows {
+		return i.binName + ".exe"
+	}
+	return i.binName
+}
+
+// Requires returns no requirement if a prebuilt binary is declared for the platform,
+// in which case cargo is looked up only when falling back to it.
+func (i *CargoInstaller) Requires() []string {
+	if i.hasPrebuilt() {
+		return noRequires
+	}
+	return []string{"cargo"}
+}
+
+func (i *CargoInstaller) Kind() string {
+	return kindCargo
+}
+
+// hasPrebuilt reports whether a prebuilt binary is declared for the platform.
+func (i *CargoInstaller) hasPrebuilt() bool {
+	if i.prebuilt == nil {
+		return false
+	}
+	if len(i.prebuilt.Supports) == 0 {
+		return true
+	}
+	p := i.platform()
+	for _, s := range i.prebuilt.Supports {
+		if s == p.os+"/"+p.arch {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *CargoInstaller) packageSpec() string {
+	if v := i.Version(); v != versionUnSpecified {
+		return i.crate + "@" + v
+	}
+	return i.crate
+}
+
+func (i *CargoInstaller) Install(ctx context.Context, dir string) error {
+	if i.hasPrebuilt() {
+		err := i.installPrebuilt(ctx, dir)
+		if !errors.Is(err, errNoPrebuilt) {
+			return err
+		}
+		if _, lerr := exec.LookPath("cargo"); lerr != nil {
+			return fmt.Errorf("%v, and cargo is required to build %s: %w", err, i.crate, lerr)
+		}
+		i.logger().Printf("%s: %v, falling back to cargo", i.Name(), err)
+		if err := cleanDir(dir); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"install", "--root", dir, "--locked"}
+	if v := i.Version(); v != versionUnSpecified {
+		args = append(args, "--version", v)
+	}
+	if len(i.features) != 0 {
+		args = append(args, "--features", strings.Join(i.features, ","))
+	}
+	if err := i.CmdRun(ctx, dir, "cargo", i.withInstallArgs(args, i.crate)...); err != nil {
+		return err
+	}
+	i.record("crates:"+i.packageSpec(), "")
+	if v, err := i.installedVersion(dir); err == nil {
+		i.resolvedVersion = v
+	} else {
+		i.logger().Println(err)
+	}
+	return os.Symlink(filepath.Join("bin", i.BinName()), filepath.Join(dir, i.BinName()))
+}
+
+// errNoPrebuilt means that no prebuilt binary is published for the version and the platform.
+var errNoPrebuilt = errors.New("prebuilt binary is not available")
+
+// installPrebuilt downloads the prebuilt binary into dir as an archive installer does.
+// It fails with errNoPrebuilt only if the binary is not found, and any other error must not fall back to cargo.
+func (i *CargoInstaller) installPrebuilt(ctx context.Context, dir string) error {
+	bin := i.prebuilt.Bin
+	if bin == "" {
+		bin = i.binName + "{{.Exe}}"
+	}
+	d := i.templateData(i.Name()).withAliases(i.platform(), i.prebuilt.Aliases)
+	u, err := renderTemplate(i.prebuilt.URL, d)
+	if err != nil {
+		return err
+	}
+	if bin, err = renderTemplate(bin, d); err != nil {
+		return err
+	}
+	if err := i.installArtifact(ctx, dir, u, bin, i.BinName()); err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("%w: %v", errNoPrebuilt, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// cleanDir removes everything in dir.
+func cleanDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := os.RemoveAll(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installedVersion reads the crate version from .crates.toml written by cargo install into dir.
+func (i *CargoInstaller) installedVersion(dir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".crates.toml"))
+	if err != nil {
+		return "", err
+	}
+	re := regexp.MustCompile(`"` + regexp.QuoteMeta(i.crate) + ` (\S+) `)
+	m := re.FindSubmatch(b)
+	if m == nil {
+		return "", fmt.Errorf("version of %s not found in .crates.toml", i.crate)
+	}
+	return string(m[1]), nil
+}
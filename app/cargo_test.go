@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCargo puts a fake cargo command into PATH, which records its arguments into args.
+func fakeCargo(t *testing.T) (args string) {
+	t.Helper()
+	bin := t.TempDir()
+	args = filepath.Join(bin, "args")
+	script := `#!/bin/sh
+echo "$@" > ` + args + `
+root=$3
+mkdir -p "$root/bin"
+printf '#!/bin/sh\n' > "$root/bin/foo-ls"
+chmod +x "$root/bin/foo-ls"
+echo '[v1]' > "$root/.crates.toml"
+echo '"foo-ls-cli 0.3.1 (registry+https://github.com/rust-lang/crates.io-index)" = ["foo-ls"]' >> "$root/.crates.toml"
+`
+	if err := ioutil.WriteFile(filepath.Join(bin, "cargo"), []byte(script), 0777); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return args
+}
+
+func TestCargoInstaller(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	args := fakeCargo(t)
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: cargo
+    package: foo-ls-cli
+    bin: foo-ls
+    features: [lsp, toml]
+`)
+	assert.Equal(t, []string{"cargo"}, i.Requires())
+	if err := a.Install(context.Background(), "foo-ls@0.3.1"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "install --root "+stagingDir(i.Dir())+" --locked --version 0.3.1 --features lsp,toml foo-ls-cli\n", string(b))
+	assert.True(t, isInstalled(i))
+	r, err := readReceipt(i.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "0.3.1", r.Version)
+	assert.Equal(t, "crates:foo-ls-cli@0.3.1", r.Source)
+}
+
+func TestCargoInstaller_installArgs(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	args := fakeCargo(t)
+	a, _ := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: cargo
+    package: foo-ls-cli
+    bin: foo-ls
+    install_args: [--offline]
+`)
+	if err := a.Install(context.Background(), "foo-ls@0.3.1"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(b), "--offline foo-ls-cli\n")
+}
+
+func TestCargoInstaller_prebuilt(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/0.3.1/foo-ls" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer ts.Close()
+	registry := `
+servers:
+  - name: foo-ls
+    kind: cargo
+    package: foo-ls-cli
+    bin: foo-ls
+    prebuilt:
+      url: ` + ts.URL + `/{{.Version}}/foo-ls
+      supports: [` + runtime.GOOS + "/" + runtime.GOARCH + `]
+`
+
+	t.Run("prebuilt", func(t *testing.T) {
+		args := fakeCargo(t)
+		a, i := newRegistryTestApp(t, registry)
+		assert.Empty(t, i.Requires())
+		if err := a.Install(context.Background(), "foo-ls@0.3.1"); err != nil {
+			t.Fatal(err)
+		}
+		_, err := os.Stat(args)
+		assert.True(t, os.IsNotExist(err), "cargo should not run")
+		r, err := readReceipt(i.Dir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, ts.URL+"/0.3.1/foo-ls", r.Source)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		args := fakeCargo(t)
+		a, i := newRegistryTestApp(t, registry)
+		if err := a.Install(context.Background(), "foo-ls@0.3.0"); err != nil {
+			t.Fatal(err)
+		}
+		_, err := os.Stat(args)
+		assert.NoError(t, err, "cargo should run")
+		r, err := readReceipt(i.Dir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "crates:foo-ls-cli@0.3.0", r.Source)
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		args := fakeCargo(t)
+		a, _ := newRegistryTestApp(t, registry+"    sha256: {foo-ls: "+strings.Repeat("0", 64)+"}\n")
+		assert.ErrorContains(t, a.Install(context.Background(), "foo-ls@0.3.1"), "checksum mismatch")
+		_, err := os.Stat(args)
+		assert.True(t, os.IsNotExist(err), "cargo should not run")
+	})
+
+	t.Run("fallback without cargo", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		a, _ := newRegistryTestApp(t, registry)
+		err := a.Install(context.Background(), "foo-ls@0.3.0")
+		assert.ErrorContains(t, err, "prebuilt binary is not available")
+		assert.ErrorContains(t, err, "cargo is required to build foo-ls-cli")
+	})
+}
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	checkOK     = "ok"
+	checkFailed = "failed"
+
+	// DefaultCheckTimeout is the default time limit of a check.
+	DefaultCheckTimeout = 30 * time.Second
+)
+
+type checkResult struct {
+	Name          string   `json:"name"`
+	Status        string   `json:"status"`
+	ServerName    string   `json:"server_name"`
+	ServerVersion string   `json:"server_version"`
+	Capabilities  []string `json:"capabilities"`
+	Error         string   `json:"error"`
+}
+
+// jsonrpcMessage is a JSON-RPC 2.0 request, notification or response read from a language server.
+type jsonrpcMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonrpcError   `json:"error,omitempty"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+// lspConn speaks JSON-RPC with the Content-Length framing of LSP.
+type lspConn struct {
+	r  *bufio.Reader
+	w  io.Writer
+	id int
+}
+
+func newLSPConn(r io.Reader, w io.Writer) *lspConn {
+	return &lspConn{r: bufio.NewReader(r), w: w}
+}
+
+func (c *lspConn) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	return err
+}
+
+func (c *lspConn) read() (*jsonrpcMessage, error) {
+	length := -1
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(k, "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return nil, fmt.Errorf("invalid header %q", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("no Content-Length header")
+	}
+	b := make([]byte, length)
+	if _, err := io.ReadFull(c.r, b); err != nil {
+		return nil, err
+	}
+	var m jsonrpcMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid message %q: %w", b, err)
+	}
+	return &m, nil
+}
+
+func (c *lspConn) notify(method string, params interface{}) error {
+	return c.write(&jsonrpcRequest{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// call sends a request and waits for its response.
+// Requests from the server meanwhile are answered with null results, and notifications are ignored.
+func (c *lspConn) call(method string, params interface{}) (json.RawMessage, error) {
+	c.id++
+	if err := c.write(&jsonrpcRequest{JSONRPC: "2.0", ID: c.id, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+	for {
+		m, err := c.read()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", method, err)
+		}
+		if m.Method != "" {
+			if len(m.ID) != 0 {
+				if err := c.reply(m); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if string(m.ID) != strconv.Itoa(c.id) {
+			continue
+		}
+		if m.Error != nil {
+			return nil, fmt.Errorf("%s: %s (%d)", method, m.Error.Message, m.Error.Code)
+		}
+		return m.Result, nil
+	}
+}
+
+// reply answers a request from the server as a client without any configuration.
+func (c *lspConn) reply(m *jsonrpcMessage) error {
+	var result interface{}
+	if m.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		_ = json.Unmarshal(m.Params, &params)
+		result = make([]interface{}, len(params.Items))
+	}
+	return c.write(&jsonrpcResponse{JSONRPC: "2.0", ID: m.ID, Result: result})
+}
+
+// fileURI returns the file URI of the absolute path.
+func fileURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// handshake initializes the language server, then shuts it down, and returns the InitializeResult.
+func handshake(c *lspConn, dir string) (*checkResult, error) {
+	uri := fileURI(dir)
+	b, err := c.call("initialize", map[string]interface{}{
+		"processId":        os.Getpid(),
+		"clientInfo":       map[string]string{"name": appName, "version": lsmVersion()},
+		"rootPath":         dir,
+		"rootUri":          uri,
+		"workspaceFolders": []map[string]string{{"uri": uri, "name": filepath.Base(dir)}},
+		"capabilities":     map[string]interface{}{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+		ServerInfo   struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, fmt.Errorf("invalid InitializeResult: %w", err)
+	}
+	r := &checkResult{
+		ServerName:    result.ServerInfo.Name,
+		ServerVersion: result.ServerInfo.Version,
+		Capabilities:  make([]string, 0, len(result.Capabilities)),
+	}
+	for k, v := range result.Capabilities {
+		if s := string(v); s != "false" && s != "null" {
+			r.Capabilities = append(r.Capabilities, k)
+		}
+	}
+	sort.Strings(r.Capabilities)
+
+	if err := c.notify("initialized", struct{}{}); err != nil {
+		return nil, err
+	}
+	if _, err := c.call("shutdown", nil); err != nil {
+		return nil, err
+	}
+	if err := c.notify("exit", nil); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// checkServer launches the installed language server over stdio in dir and runs the handshake within the timeout.
+func (a *App) checkServer(ctx context.Context, name, dir string, timeout time.Duration) (*checkResult, error) {
+	p, err := a.executable(name)
+	if err != nil {
+		return nil, err
+	}
+	i, _ := a.getInstaller(name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p, launchArgs(i, i.base().stdioArgs)...)
+	cmd.Dir = dir
+	cmd.Env = launchEnv(i)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	// a file rather than a pipe, so that Wait does not wait for the processes inheriting stderr
+	stderr, err := ioutil.TempFile("", "lsm-check-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	type handshakeResult struct {
+		r   *checkResult
+		err error
+	}
+	done := make(chan handshakeResult, 1)
+	go func() {
+		r, err := handshake(newLSPConn(stdout, stdin), dir)
+		stdin.Close()
+		done <- handshakeResult{r, err}
+	}()
+	// the process may leave the pipes open on failure, so the handshake is abandoned at the deadline
+	var res handshakeResult
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("no response within %v", timeout)
+	}
+	if res.err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, withStderr(res.err, stderr.Name())
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, withStderr(fmt.Errorf("exit: %w", err), stderr.Name())
+	}
+	return res.r, nil
+}
+
+// withStderr appends the last line of the stderr of the language server written in the file to err.
+func withStderr(err error, file string) error {
+	b, rerr := ioutil.ReadFile(file)
+	if rerr != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return fmt.Errorf("%w: %s", err, last)
+	}
+	return err
+}
+
+// Check launches the installed language servers over stdio, initializes and shuts them down,
+// and shows the server names, versions and capabilities they advertise.
+// All the installed language servers are checked if no name is given.
+func (a *App) Check(ctx context.Context, names []string, timeout time.Duration, style ListStyle) error {
+	results, err := a.checkAll(ctx, names, timeout)
+	if err != nil {
+		return err
+	}
+	if err := a.render(results, style); err != nil {
+		return err
+	}
+	return checkFailures(results)
+}
+
+// checkAll checks the language servers, or all the installed ones if no name is given.
+func (a *App) checkAll(ctx context.Context, names []string, timeout time.Duration) ([]checkResult, error) {
+	if len(names) == 0 {
+		for name, i := range a.installers {
+			if i.BinName() != noExecutable && isInstalled(i) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]checkResult, 0, len(names))
+	for _, name := range names {
+		r, err := a.checkServer(ctx, name, dir, timeout)
+		if err != nil {
+			r = &checkResult{Status: checkFailed, Error: err.Error()}
+		} else {
+			r.Status = checkOK
+		}
+		r.Name = name
+		results = append(results, *r)
+	}
+	return results, nil
+}
+
+func checkFailures(results []checkResult) error {
+	var failed int
+	for _, r := range results {
+		if r.Status == checkFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
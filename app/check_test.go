@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHelperLanguageServer is not a test but a fake language server launched by the tests of check,
+// which behaves as LSM_TEST_LANGUAGE_SERVER says.
+func TestHelperLanguageServer(t *testing.T) {
+	mode := os.Getenv("LSM_TEST_LANGUAGE_SERVER")
+	if mode == "" {
+		t.Skip("helper process")
+	}
+	defer os.Exit(0)
+	switch mode {
+	case "crash":
+		fmt.Fprintln(os.Stderr, "env: node: No such file or directory")
+		os.Exit(127)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+
+	c := newLSPConn(os.Stdin, os.Stdout)
+	for {
+		m, err := c.read()
+		if err != nil {
+			os.Exit(1)
+		}
+		switch m.Method {
+		case "initialize":
+			// a server may ask the configuration before it responds
+			if err := c.write(&jsonrpcRequest{JSONRPC: "2.0", ID: 100, Method: "workspace/configuration", Params: map[string]interface{}{"items": []struct{}{{}, {}}}}); err != nil {
+				os.Exit(1)
+			}
+			if r, err := c.read(); err != nil || string(r.Result) != "[null,null]" {
+				os.Exit(2)
+			}
+			_ = c.write(&jsonrpcRequest{JSONRPC: "2.0", Method: "window/logMessage", Params: map[string]interface{}{"type": 3, "message": "hello"}})
+			_ = c.write(&jsonrpcResponse{JSONRPC: "2.0", ID: m.ID, Result: map[string]interface{}{
+				"capabilities": map[string]interface{}{
+					"hoverProvider":      true,
+					"definitionProvider": false,
+					"textDocumentSync":   1,
+					"codeActionProvider": map[string]interface{}{"codeActionKinds": []string{"quickfix"}},
+				},
+				"serverInfo": map[string]string{"name": "fake-ls", "version": strings.Join(os.Args[len(os.Args)-1:], "")},
+			}})
+		case "shutdown":
+			_ = c.write(&jsonrpcResponse{JSONRPC: "2.0", ID: m.ID})
+		case "exit":
+			return
+		}
+	}
+}
+
+func newCheckTestApp(t *testing.T, mode string) *App {
+	t.Helper()
+	if isWindows {
+		t.Skip("shell script")
+	}
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := newFakeInstaller(a.baseDir, "fake-ls")
+	i.stdioArgs = []string{"--stdio"}
+	a.installers[i.Name()] = i
+	fakeInstall(t, i, "1.0.0")
+	script := fmt.Sprintf("#!/bin/sh\nLSM_TEST_LANGUAGE_SERVER=%s exec %s -test.run=TestHelperLanguageServer -- \"$@\"\n", mode, os.Args[0])
+	if err := ioutil.WriteFile(filepath.Join(i.Dir(), i.BinName()), []byte(script), 0777); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestApp_Check(t *testing.T) {
+	a := newCheckTestApp(t, "ok")
+	var out bytes.Buffer
+	a.out = &out
+	if err := a.Check(context.Background(), nil, 0, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var results []checkResult
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []checkResult{{
+		Name:          "fake-ls",
+		Status:        checkOK,
+		ServerName:    "fake-ls",
+		ServerVersion: "--stdio",
+		Capabilities:  []string{"codeActionProvider", "hoverProvider", "textDocumentSync"},
+	}}, results)
+}
+
+func TestApp_Check_failed(t *testing.T) {
+	tests := map[string]string{
+		"crash": "env: node: No such file or directory",
+		"hang":  "no response within 200ms",
+	}
+	for mode, want := range tests {
+		t.Run(mode, func(t *testing.T) {
+			a := newCheckTestApp(t, mode)
+			var out bytes.Buffer
+			a.out = &out
+			err := a.Check(context.Background(), []string{"fake-ls"}, 200*time.Millisecond, ListStyleJSON)
+			assert.EqualError(t, err, "1 of 1 checks failed")
+			var results []checkResult
+			if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, checkFailed, results[0].Status)
+			assert.Contains(t, results[0].Error, want)
+		})
+	}
+}
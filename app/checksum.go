@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// errChecksumMismatch means that a downloaded file does not match its declared digest.
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// checksums declares the expected SHA-256 digests of downloaded files.
+type checksums struct {
+	// digests maps a downloaded URL, or its file name, to its hex encoded digest.
+	// A digest keyed by the URL takes precedence, since files of the same name may be downloaded from different URLs.
+	digests map[string]string
+	// url is a template of a checksums file in the sha256sum format.
+	url string
+}
+
+func normalizeDigest(d string) string {
+	return strings.ToLower(strings.TrimPrefix(d, "sha256:"))
+}
+
+// parseChecksums parses lines of "<digest>  <file name>" as sha256sum outputs.
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	digests := make(map[string]string)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		digests[strings.TrimPrefix(fields[1], "*")] = normalizeDigest(fields[0])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return digests, nil
+}
+
+func (i *baseInstaller) fetchChecksums(ctx context.Context) (map[string]string, error) {
+	u, err := renderTemplate(i.checksums.url, i.data)
+	if err != nil {
+		return nil, err
+	}
+	if i.cache != nil {
+		if b, ok := i.cache.read(u); ok {
+			return parseChecksums(bytes.NewReader(b))
+		}
+	}
+	// fetched with the proxy and the retry policy of downloads
+	c := &registryClient{client: i.httpClient(), retry: i.retry, logger: i.logger()}
+	b, err := c.get(ctx, u, "*/*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	if i.cache != nil {
+		if err := i.cache.put(u, bytes.NewReader(b)); err != nil {
+			i.logger().Println(err)
+		}
+	}
+	return parseChecksums(bytes.NewReader(b))
+}
+
+// expectedChecksum returns the declared digest of the file downloaded from u, or an empty string if none is declared.
+// A checksums file lists the files by name, as it is published next to them.
+func (i *baseInstaller) expectedChecksum(ctx context.Context, u string) (string, error) {
+	if d, ok := i.checksums.digests[u]; ok {
+		return normalizeDigest(d), nil
+	}
+	file, err := urlFileName(u)
+	if err != nil {
+		return "", err
+	}
+	if d, ok := i.checksums.digests[file]; ok {
+		return normalizeDigest(d), nil
+	}
+	if i.checksums.url == "" {
+		return "", nil
+	}
+	digests, err := i.fetchChecksums(ctx)
+	if err != nil {
+		return "", err
+	}
+	d, ok := digests[file]
+	if !ok {
+		return "", fmt.Errorf("checksum of %s is not found in %s", file, i.checksums.url)
+	}
+	return d, nil
+}
+
+// verifyChecksum verifies the digest of the file downloaded from u if any digest is declared.
+func (i *baseInstaller) verifyChecksum(ctx context.Context, u, digest string) error {
+	if i.insecureSkipVerify {
+		return nil
+	}
+	want, err := i.expectedChecksum(ctx, u)
+	if err != nil {
+		return err
+	}
+	if want == "" {
+		if i.requireChecksum {
+			return fmt.Errorf("checksum of %s is not declared", u)
+		}
+		return nil
+	}
+	if want != normalizeDigest(digest) {
+		return fmt.Errorf("%w for %s: expected sha256:%s, got %s", errChecksumMismatch, u, want, digest)
+	}
+	return nil
+}
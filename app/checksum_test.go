@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseChecksums(t *testing.T) {
+	const sums = `
+0123abcd  terraform-ls_0.2.0_darwin_amd64.zip
+4567EF01 *terraform-ls_0.2.0_linux_amd64.zip
+`
+	got, err := parseChecksums(strings.NewReader(sums))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{
+		"terraform-ls_0.2.0_darwin_amd64.zip": "0123abcd",
+		"terraform-ls_0.2.0_linux_amd64.zip":  "4567ef01",
+	}, got)
+}
+
+func TestApp_Install_checksum(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/1.0.0/foo-ls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	})
+	mux.HandleFunc("/1.0.0/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  foo-ls\n", digest)
+	})
+	mux.HandleFunc("/2.0.0/SHA256SUMS", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  foo-ls\n", strings.Repeat("0", 64))
+	})
+	mux.HandleFunc("/2.0.0/foo-ls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		name, entry, spec string
+		skipVerify        bool
+		wantErr           bool
+	}{
+		{
+			name:  "digest",
+			entry: "sha256: {foo-ls: " + digest + "}",
+			spec:  "foo-ls@1.0.0",
+		},
+		{
+			name:    "digest mismatch",
+			entry:   "sha256: {foo-ls: sha256:" + strings.Repeat("0", 64) + "}",
+			spec:    "foo-ls@1.0.0",
+			wantErr: true,
+		},
+		{
+			name:  "digest by URL",
+			entry: `sha256: {"` + ts.URL + `/1.0.0/foo-ls": ` + digest + `, foo-ls: ` + strings.Repeat("0", 64) + `}`,
+			spec:  "foo-ls@1.0.0",
+		},
+		{
+			// the digest of another file of the same name does not apply
+			name:  "digest by other URL",
+			entry: `sha256: {"https://example.com/1.0.0/foo-ls": ` + strings.Repeat("0", 64) + `}`,
+			spec:  "foo-ls@1.0.0",
+		},
+		{
+			name:  "checksums file",
+			entry: "checksums_url: " + ts.URL + "/{{.Version}}/SHA256SUMS",
+			spec:  "foo-ls@1.0.0",
+		},
+		{
+			name:    "checksums file mismatch",
+			entry:   "checksums_url: " + ts.URL + "/{{.Version}}/SHA256SUMS",
+			spec:    "foo-ls@2.0.0",
+			wantErr: true,
+		},
+		{
+			name:       "skip verify",
+			entry:      "checksums_url: " + ts.URL + "/{{.Version}}/SHA256SUMS",
+			spec:       "foo-ls@2.0.0",
+			skipVerify: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    url: `+ts.URL+`/{{.Version}}/foo-ls
+    bin: foo-ls
+    `+tt.entry+`
+`)
+			a.SetInsecureSkipVerify(tt.skipVerify)
+			err := a.Install(context.Background(), tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.False(t, isInstalled(i))
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, isInstalled(i))
+		})
+	}
+}
+
+func TestBaseInstaller_fetchChecksums_retry(t *testing.T) {
+	var n int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "%s  foo-ls\n", strings.Repeat("0", 64))
+	}))
+	defer ts.Close()
+
+	b := newRetryTestInstaller(t)
+	b.checksums.url = ts.URL + "/SHA256SUMS"
+	d, err := b.expectedChecksum(context.Background(), ts.URL+"/foo-ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Repeat("0", 64), d)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&n))
+}
+
+func TestApp_mergeRegistry_amend(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := parseRegistry([]byte(`
+servers:
+  - name: efm-langserver
+    sha256:
+      efm-langserver_v0.0.14_linux_amd64.tar.gz: 0123abcd
+`), ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.mergeRegistry(r); err != nil {
+		t.Fatal(err)
+	}
+	i, err := a.getInstaller("efm-langserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.IsType(t, &GitHubReleaseInstaller{}, i)
+	assert.NotEmpty(t, i.base().checksums.digests)
+
+	r, err = parseRegistry([]byte(`{"servers": [{"name": "unknown-ls", "checksums_url": "https://example.com"}]}`), ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, a.mergeRegistry(r))
+}
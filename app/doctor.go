@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	diagnosisOK      = "ok"
+	diagnosisWarning = "warning"
+	diagnosisError   = "error"
+
+	baseDirCheck = "base dir"
+
+	// minNodeVersion and minNpmVersion are the oldest versions the npm language servers in the registry run with.
+	minNodeVersion = "14.0.0"
+	minNpmVersion  = "6.0.0"
+)
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+){0,2}`)
+
+// diagnosis is a result of a check of the environment.
+type diagnosis struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint"`
+}
+
+type installability struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Installable bool   `json:"installable"`
+	Reason      string `json:"reason"`
+}
+
+type doctorReport struct {
+	Checks  []diagnosis      `json:"checks"`
+	Servers []installability `json:"servers"`
+}
+
+func passed(check, detail string) diagnosis {
+	return diagnosis{Check: check, Status: diagnosisOK, Detail: detail}
+}
+
+// firstLine returns the first non-empty line of the command output.
+func firstLine(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// checkCommand checks that the command is found and runs with the version args.
+func checkCommand(ctx context.Context, hint, name string, args ...string) diagnosis {
+	if _, err := exec.LookPath(name); err != nil {
+		return diagnosis{Check: name, Status: diagnosisError, Detail: err.Error(), Hint: hint}
+	}
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return diagnosis{Check: name, Status: diagnosisError, Detail: fmt.Sprintf("%v: %s", err, firstLine(out)), Hint: hint}
+	}
+	return passed(name, firstLine(out))
+}
+
+// checkMinVersion checks that the command is found and reports a version of min or later.
+func checkMinVersion(ctx context.Context, hint, min, name string, args ...string) diagnosis {
+	return minVersion(checkCommand(ctx, hint, name, args...), hint, min)
+}
+
+// minVersion turns the passed diagnosis of a command into an error if the version in its detail is older than min.
+func minVersion(d diagnosis, hint, min string) diagnosis {
+	if d.Status != diagnosisOK {
+		return d
+	}
+	v, err := semver.NewVersion(versionPattern.FindString(d.Detail))
+	if err != nil {
+		return diagnosis{Check: d.Check, Status: diagnosisWarning, Detail: "unknown version: " + d.Detail, Hint: hint}
+	}
+	if v.LessThan(semver.MustParse(min)) {
+		return diagnosis{Check: d.Check, Status: diagnosisError, Detail: fmt.Sprintf("%s is older than %s", d.Detail, min), Hint: hint}
+	}
+	return d
+}
+
+func checkPython(ctx context.Context) diagnosis {
+	const hint = "install Python 3.5 or later with the venv module, e.g. python3-venv on Debian"
+	py, err := _lookPython()
+	if err != nil {
+		return diagnosis{Check: python, Status: diagnosisError, Detail: err.Error(), Hint: hint}
+	}
+	v, err := pythonVersion(py)
+	if err != nil {
+		return diagnosis{Check: python, Status: diagnosisError, Detail: err.Error(), Hint: hint}
+	}
+	tmp, err := ioutil.TempDir("", "lsm-doctor-")
+	if err != nil {
+		return diagnosis{Check: python, Status: diagnosisError, Detail: err.Error()}
+	}
+	defer os.RemoveAll(tmp)
+	if out, err := exec.CommandContext(ctx, py, "-m", "venv", filepath.Join(tmp, "venv")).CombinedOutput(); err != nil {
+		return diagnosis{Check: python, Status: diagnosisError, Detail: fmt.Sprintf("%s -m venv: %v: %s", py, err, firstLine(out)), Hint: hint}
+	}
+	return passed(python, fmt.Sprintf("%s %s with venv", py, v))
+}
+
+func checkCC(ctx context.Context) diagnosis {
+	const check = "cc"
+	if _, err := exec.LookPath("go"); err != nil {
+		return diagnosis{Check: check, Status: diagnosisWarning, Detail: "go is not found", Hint: "install Go to check the C compiler used by cgo"}
+	}
+	cc, err := lookCC(ctx)
+	if err != nil {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: err.Error(), Hint: "install a C compiler, or set CC for language servers that use cgo such as sqls"}
+	}
+	return passed(check, cc)
+}
+
+func checkWritable(dir string) diagnosis {
+	const check = baseDirCheck
+	hint := "check the permissions of " + dir
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: err.Error(), Hint: hint}
+	}
+	f, err := ioutil.TempFile(dir, ".lsm-doctor-")
+	if err != nil {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: err.Error(), Hint: hint}
+	}
+	f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: err.Error(), Hint: hint}
+	}
+	return passed(check, dir+" is writable")
+}
+
+// checkNetwork checks that the registry is reachable, through the proxy if any.
+func (a *App) checkNetwork(ctx context.Context, name, u string) diagnosis {
+	check := "network (" + name + ")"
+	const hint = "check the network connection and the proxy in the config file, or HTTPS_PROXY, HTTP_PROXY and NO_PROXY"
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: err.Error()}
+	}
+	via := "directly"
+	if proxy, err := a.proxyURL(req); err == nil && proxy != nil {
+		via = "via " + proxy.Redacted()
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: fmt.Sprintf("%v (%s)", err, via), Hint: hint}
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return diagnosis{Check: check, Status: diagnosisError, Detail: fmt.Sprintf("%s responded %s (%s)", u, resp.Status, via), Hint: hint}
+	}
+	return passed(check, fmt.Sprintf("%s is reachable %s", u, via))
+}
+
+func (a *App) diagnose(ctx context.Context) []diagnosis {
+	return []diagnosis{
+		checkCommand(ctx, "install Go from https://go.dev/dl/", "go", "version"),
+		checkCommand(ctx, "install Rust from https://rustup.rs/", "cargo", "--version"),
+		checkMinVersion(ctx, "install Node.js "+minNodeVersion+" or later from https://nodejs.org/", minNodeVersion, "node", "--version"),
+		checkMinVersion(ctx, "install npm "+minNpmVersion+" or later, which is bundled with Node.js", minNpmVersion, "npm", "--version"),
+		checkPython(ctx),
+		checkCommand(ctx, "install a JDK, e.g. from https://adoptium.net/", "java", "-version"),
+		checkCC(ctx),
+		checkWritable(a.baseDir),
+		a.checkNetwork(ctx, "npm", a.endpoints.NpmRegistry),
+		a.checkNetwork(ctx, "pypi", a.endpoints.PyPI),
+		a.checkNetwork(ctx, "go proxy", a.endpoints.GoProxy),
+		a.checkNetwork(ctx, "github", a.endpoints.GitHubAPI),
+		a.checkNetwork(ctx, "crates", a.endpoints.Crates),
+	}
+}
+
+// prerequisites returns the checks of diagnose that the installer depends on.
+func prerequisites(i Installer) []string {
+	checks := append([]string{baseDirCheck}, i.Requires()...)
+	if i.Kind() == kindPip {
+		checks = append(checks, python)
+	}
+	return checks
+}
+
+// Doctor checks the prerequisites of the installers and shows which language servers are installable.
+// A language server is not installable if any check it depends on fails, e.g. an old node for the npm ones.
+func (a *App) Doctor(ctx context.Context, style ListStyle) error {
+	report := doctorReport{Checks: a.diagnose(ctx)}
+	failed := make(map[string]diagnosis)
+	for _, d := range report.Checks {
+		if d.Status == diagnosisError {
+			failed[d.Check] = d
+		}
+	}
+	for _, i := range a.installers {
+		s := installability{Name: i.Name(), Kind: i.Kind(), Installable: true}
+		// the checks of the installer use the proxy and the retry policy as the installation does
+		err := a.prepare(i)
+		if err == nil {
+			err = checkInstallable(ctx, i)
+		}
+		if err != nil {
+			s.Installable = false
+			s.Reason = err.Error()
+		} else {
+			for _, check := range prerequisites(i) {
+				if d, ok := failed[check]; ok {
+					s.Installable = false
+					s.Reason = fmt.Sprintf("%s: %s", d.Check, d.Detail)
+					break
+				}
+			}
+		}
+		report.Servers = append(report.Servers, s)
+	}
+	sort.Slice(report.Servers, func(x, y int) bool {
+		return report.Servers[x].Name < report.Servers[y].Name
+	})
+
+	switch a.listStyle(style) {
+	case ListStyleJSON:
+		return a.renderJSON(report)
+	case ListStyleTable, ListStyleUndefined:
+		if err := a.renderTable(report.Checks); err != nil {
+			return err
+		}
+		return a.renderTable(report.Servers)
+	default:
+		return fmt.Errorf("unsupported list style: %v", style)
+	}
+}
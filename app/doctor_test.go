@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Doctor(t *testing.T) {
+	// go and cargo write their state such as telemetry under HOME
+	t.Setenv("HOME", t.TempDir())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetEndpoints(EndpointsFor(ts.URL))
+	a.installers = map[string]Installer{}
+	for _, name := range []string{"a-ls", "b-ls"} {
+		a.installers[name] = newFakeInstaller(a.baseDir, name)
+	}
+	a.installers["b-ls"].(*fakeInstaller).requires = []string{"lsm-no-such-command"}
+	// node is found but too old
+	a.installers["c-ls"] = newFakeInstaller(a.baseDir, "c-ls")
+	a.installers["c-ls"].(*fakeInstaller).requires = []string{"node"}
+	bin := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(bin, "node"), []byte("#!/bin/sh\necho v12.0.0\n"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var buf bytes.Buffer
+	a.out = &buf
+	if err := a.Doctor(context.Background(), ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var report doctorReport
+	if err := json.NewDecoder(&buf).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := make(map[string]diagnosis)
+	for _, d := range report.Checks {
+		checks[d.Check] = d
+		if d.Status != diagnosisOK {
+			assert.NotEmpty(t, d.Hint, d.Check)
+		}
+	}
+	for _, name := range []string{"go", "node", "npm", "python", "java", "cc", "base dir"} {
+		assert.Contains(t, checks, name)
+	}
+	assert.Equal(t, diagnosisOK, checks["base dir"].Status)
+	for _, name := range []string{"npm", "pypi", "go proxy", "github"} {
+		assert.Equal(t, diagnosisOK, checks["network ("+name+")"].Status, name)
+	}
+
+	assert.Equal(t, diagnosisError, checks["node"].Status)
+	if assert.Len(t, report.Servers, 3) {
+		assert.Equal(t, installability{Name: "a-ls", Kind: "fake", Installable: true}, report.Servers[0])
+		assert.Equal(t, "b-ls", report.Servers[1].Name)
+		assert.False(t, report.Servers[1].Installable)
+		assert.Contains(t, report.Servers[1].Reason, "b-ls requires lsm-no-such-command")
+		assert.Equal(t, "c-ls", report.Servers[2].Name)
+		assert.False(t, report.Servers[2].Installable)
+		assert.Contains(t, report.Servers[2].Reason, "node: v12.0.0 is older than "+minNodeVersion)
+	}
+}
+
+func Test_minVersion(t *testing.T) {
+	const hint = "install node"
+	tests := []struct {
+		detail string
+		status string
+	}{
+		{"v18.19.0", diagnosisOK},
+		{"v14.0.0", diagnosisOK},
+		{"v12.22.9", diagnosisError},
+		{"10", diagnosisError},
+		{"unknown", diagnosisWarning},
+	}
+	for _, tt := range tests {
+		got := minVersion(passed("node", tt.detail), hint, minNodeVersion)
+		assert.Equal(t, tt.status, got.Status, tt.detail)
+		if got.Status != diagnosisOK {
+			assert.Equal(t, hint, got.Hint, tt.detail)
+		}
+	}
+	failed := diagnosis{Check: "node", Status: diagnosisError, Detail: "not found"}
+	assert.Equal(t, failed, minVersion(failed, hint, minNodeVersion))
+}
+
+func TestApp_checkNetwork(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, diagnosisOK, a.checkNetwork(context.Background(), "ok", ts.URL).Status)
+	d := a.checkNetwork(context.Background(), "limited", ts.URL+"/limited")
+	assert.Equal(t, diagnosisError, d.Status)
+	assert.Contains(t, d.Detail, "403 Forbidden")
+}
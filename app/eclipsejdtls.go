@@ -1,9 +1,13 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
+	"strings"
+	"text/template"
 )
 
 type EclipseJDTLSInstaller struct {
@@ -23,19 +27,144 @@ func (i *EclipseJDTLSInstaller) Name() string {
 }
 
 func (i *EclipseJDTLSInstaller) Version() string {
-	return "latest"
+	return i.versionOr("latest")
+}
+
+func (i *EclipseJDTLSInstaller) Kind() string {
+	return kindArchive
 }
 
 func (i *EclipseJDTLSInstaller) BinName() string {
-	return ""
+	if i.platform().os == windows {
+		return "jdtls.bat"
+	}
+	return "jdtls"
 }
 
 func (i *EclipseJDTLSInstaller) Requires() []string {
-	return noRequires
+	return []string{"java"}
+}
+
+func (i *EclipseJDTLSInstaller) archive() string {
+	return fmt.Sprintf("jdt-language-server-%s.tar.gz", i.Version())
+}
+
+func (i *EclipseJDTLSInstaller) artifactURL(s Support) (string, error) {
+	return "https://download.eclipse.org/jdtls/snapshots/" + i.archive(), nil
+}
+
+// volatile reports whether the artifact is the latest snapshot, which is published under the same URL.
+func (i *EclipseJDTLSInstaller) volatile() bool {
+	return i.Version() == "latest"
+}
+
+func (i *EclipseJDTLSInstaller) Install(ctx context.Context, dir string) error {
+	i.noCache = i.volatile()
+	u, err := i.artifactURL(i.platform())
+	if err != nil {
+		return err
+	}
+	if err := i.FetchWithExtract(ctx, u, filepath.Join(dir, i.archive())); err != nil {
+		return err
+	}
+	return i.writeLauncher(dir)
+}
+
+// jdtlsScript launches eclipse.jdt.ls as "jdtls [workspace] [args...]".
+// The workspace defaults to a directory per current directory under the user cache directory.
+// The arm configuration is used on arm64 if the version of eclipse.jdt.ls has one.
+var jdtlsScript = template.Must(template.New("jdtls").Parse(`#!/bin/sh
+# Generated by lsm. Usage: jdtls [workspace] [args...]
+set -e
+{{.Env}}dir=$(cd "$(dirname "$0")" && pwd)
+jar=$(ls "$dir"/plugins/org.eclipse.equinox.launcher_*.jar | head -n 1)
+case "$(uname -s)" in
+Darwin) config=config_mac ;;
+*) config=config_linux ;;
+esac
+case "$(uname -m)" in
+arm64 | aarch64) if [ -d "$dir/${config}_arm" ]; then config=${config}_arm; fi ;;
+esac
+if [ $# -gt 0 ] && [ "${1#-}" = "$1" ]; then
+	workspace=$1
+	shift
+else
+	workspace=${XDG_CACHE_HOME:-$HOME/.cache}/lsm/jdtls-workspace/$(pwd | cksum | cut -d ' ' -f 1)
+fi
+exec "${JAVA_HOME:+$JAVA_HOME/bin/}java" \
+	-Declipse.application=org.eclipse.jdt.ls.core.id1 \
+	-Dosgi.bundles.defaultStartLevel=4 \
+	-Declipse.product=org.eclipse.jdt.ls.core.product \
+	-Xms1g \
+	--add-modules=ALL-SYSTEM \
+	--add-opens java.base/java.util=ALL-UNNAMED \
+	--add-opens java.base/java.lang=ALL-UNNAMED \
+	-jar "$jar" \
+	-configuration "$dir/$config" \
+	-data "$workspace" \
+	{{.Args}}"$@"
+`))
+
+// jdtlsBatch launches eclipse.jdt.ls as "jdtls.bat [workspace] [args...]".
+// The first argument is the workspace unless it is a flag.
+// The workspace defaults to a directory per current directory, named after its path, under the local application data directory.
+var jdtlsBatch = template.Must(template.New("jdtls.bat").Parse(`@echo off
+rem Generated by lsm. Usage: jdtls.bat [workspace] [args...]
+setlocal
+{{.Env}}set "dir=%~dp0"
+for %%f in ("%dir%plugins\org.eclipse.equinox.launcher_*.jar") do set "jar=%%f"
+set "workspace="
+set "arg=%~1"
+if defined arg if not "%arg:~0,1%"=="-" (
+	set "workspace=%arg%"
+	shift
+)
+set "args="
+:args
+if "%~1"=="" goto run
+set "args=%args% %1"
+shift
+goto args
+:run
+set "cwd=%CD::=%"
+set "cwd=%cwd:\=_%"
+if not defined workspace set "workspace=%LOCALAPPDATA%\lsm\jdtls-workspace\%cwd%"
+set "java=java"
+if defined JAVA_HOME set "java=%JAVA_HOME%\bin\java"
+"%java%" ^
+	-Declipse.application=org.eclipse.jdt.ls.core.id1 ^
+	-Dosgi.bundles.defaultStartLevel=4 ^
+	-Declipse.product=org.eclipse.jdt.ls.core.product ^
+	-Xms1g ^
+	--add-modules=ALL-SYSTEM ^
+	--add-opens java.base/java.util=ALL-UNNAMED ^
+	--add-opens java.base/java.lang=ALL-UNNAMED ^
+	-jar "%jar%" ^
+	-configuration "%dir%config_win" ^
+	-data "%workspace%"{{.Args}}%args%
+`))
+
+func (i *EclipseJDTLSInstaller) generatesLauncher() bool {
+	return true
 }
 
-func (i *EclipseJDTLSInstaller) Install(ctx context.Context) error {
-	archive := fmt.Sprintf("jdt-language-server-%s.tar.gz", i.Version())
-	u := fmt.Sprintf("https://download.eclipse.org/jdtls/snapshots/%s", archive)
-	return i.FetchWithExtract(ctx, u, filepath.Join(i.Dir(), archive))
+// writeLauncher writes the launcher script into dir with the runtime env and args, which finds the files relative to itself.
+func (i *EclipseJDTLSInstaller) writeLauncher(dir string) error {
+	var data struct{ Env, Args string }
+	tmpl := jdtlsScript
+	if i.platform().os == windows {
+		tmpl = jdtlsBatch
+		data.Env = batchSets(i.runtimeEnv)
+		data.Args = quoteArgs(i.args, batchQuote)
+	} else {
+		data.Env = shellExports(i.runtimeEnv)
+		if len(i.args) > 0 {
+			data.Args = strings.TrimPrefix(quoteArgs(i.args, shellQuote), " ") + " "
+		}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, i.BinName()), buf.Bytes(), 0777)
 }
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEclipseJDTLSInstaller_writeLauncher(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	i := NewEclipseJDTLSInstaller(t.TempDir())
+	plugins := filepath.Join(i.Dir(), "plugins")
+	if err := os.MkdirAll(plugins, 0777); err != nil {
+		t.Fatal(err)
+	}
+	jar := filepath.Join(plugins, "org.eclipse.equinox.launcher_1.6.400.v20210924-0641.jar")
+	if err := ioutil.WriteFile(jar, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.writeLauncher(i.Dir()); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isInstalled(i))
+
+	// fake java prints the arguments
+	javaHome := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(javaHome, "bin"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(javaHome, "bin", "java"), []byte("#!/bin/sh\nfor a in \"$@\"; do echo \"$a\"; done\n"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	config := "config_linux"
+	if runtime.GOOS == darwin {
+		config = "config_mac"
+	}
+
+	run := func(t *testing.T, args ...string) []string {
+		t.Helper()
+		cmd := exec.Command(filepath.Join(i.Dir(), i.BinName()), args...)
+		cmd.Env = append(os.Environ(), "JAVA_HOME="+javaHome, "XDG_CACHE_HOME="+filepath.Join(javaHome, "cache"))
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSpace(string(out)), "\n")
+	}
+
+	args := run(t, "/path/to/workspace", "--verbose")
+	assert.Contains(t, strings.Join(args, " "), "-jar "+jar+" -configuration "+filepath.Join(i.Dir(), config)+" -data /path/to/workspace --verbose")
+
+	args = run(t)
+	data := args[len(args)-1]
+	assert.True(t, strings.HasPrefix(data, filepath.Join(javaHome, "cache", "lsm", "jdtls-workspace")+"/"), data)
+
+	i.args = []string{"--log"}
+	i.runtimeEnv = []string{"XDG_CACHE_HOME=" + filepath.Join(javaHome, "runtime")}
+	if err := i.writeLauncher(i.Dir()); err != nil {
+		t.Fatal(err)
+	}
+	args = run(t, "--verbose")
+	assert.Equal(t, []string{"--log", "--verbose"}, args[len(args)-2:])
+	assert.True(t, strings.HasPrefix(args[len(args)-3], filepath.Join(javaHome, "runtime", "lsm", "jdtls-workspace")+"/"), args[len(args)-3])
+
+	// the arm configuration is used on arm64 if any
+	if err := os.MkdirAll(filepath.Join(i.Dir(), config+"_arm"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOARCH == arm64 {
+		config += "_arm"
+	}
+	args = run(t, "/path/to/workspace")
+	assert.Contains(t, strings.Join(args, " "), "-configuration "+filepath.Join(i.Dir(), config)+" ")
+}
+
+func TestEclipseJDTLSInstaller_writeLauncher_windows(t *testing.T) {
+	i := NewEclipseJDTLSInstaller(t.TempDir())
+	i.target = Support{os: windows, arch: amd64}
+	i.args = []string{"--log"}
+	i.runtimeEnv = []string{"FOO=1"}
+	dir := t.TempDir()
+	if err := i.writeLauncher(dir); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "jdtls.bat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := string(b)
+	assert.Contains(t, script, "setlocal\nset \"FOO=1\"\r\nset \"dir=%~dp0\"\n")
+	// a workspace per current directory
+	assert.Contains(t, script, `set "workspace=%LOCALAPPDATA%\lsm\jdtls-workspace\%cwd%"`)
+	// the first argument is the workspace unless it is a flag, and the rest are passed through
+	assert.Contains(t, script, `if defined arg if not "%arg:~0,1%"=="-" (`)
+	assert.Contains(t, script, `set "args=%args% %1"`)
+	assert.Contains(t, script, `-data "%workspace%" "--log"%args%`+"\n")
+}
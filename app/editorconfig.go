@@ -0,0 +1,138 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// editorServer is a language server as editors launch it.
+type editorServer struct {
+	name        string
+	cmd         []string
+	dir         string
+	env         []string
+	filetypes   []string
+	rootMarkers []string
+}
+
+// editorServers returns the installed language servers that have executables, sorted by name.
+func (a *App) editorServers() ([]editorServer, error) {
+	names := make([]string, 0, len(a.installers))
+	for name, i := range a.installers {
+		if i.BinName() != noExecutable && isInstalled(i) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	servers := make([]editorServer, 0, len(names))
+	for _, name := range names {
+		p, err := a.executable(name)
+		if err != nil {
+			return nil, err
+		}
+		i, _ := a.getInstaller(name)
+		b := i.base()
+		s := editorServer{
+			name:        name,
+			cmd:         append([]string{p}, launchArgs(i, b.stdioArgs)...),
+			dir:         i.Dir(),
+			filetypes:   b.filetypes,
+			rootMarkers: b.rootMarkers,
+		}
+		// the runtime env is applied by the launcher itself if lsm generates it
+		if !generatesLauncher(i) {
+			s.env = b.runtimeEnv
+		}
+		servers = append(servers, s)
+	}
+	return servers, nil
+}
+
+// EditorConfig shows the configuration of the editor to launch all the installed language servers directly.
+// Filetypes and root markers of the language servers are taken from the registry.
+func (a *App) EditorConfig(ctx context.Context, editor string) error {
+	var write func([]editorServer) []byte
+	switch editor {
+	case "neovim", "nvim":
+		write = neovimConfig
+	default:
+		return fmt.Errorf("unsupported editor: %s", editor)
+	}
+	servers, err := a.editorServers()
+	if err != nil {
+		return err
+	}
+	_, err = a.out.Write(write(servers))
+	return err
+}
+
+// neovimConfig returns a Lua snippet that configures and enables the language servers with vim.lsp.config of Neovim 0.11 or later.
+// Language servers without filetypes are left commented out since Neovim cannot tell when to launch them.
+func neovimConfig(servers []editorServer) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("-- generated by lsm editor-config neovim\n")
+	for _, s := range servers {
+		buf.WriteString("\n")
+		if len(s.filetypes) == 0 {
+			fmt.Fprintf(&buf, "-- %s: no filetypes in the registry\n", s.name)
+			continue
+		}
+		rootMarkers := s.rootMarkers
+		if len(rootMarkers) == 0 {
+			rootMarkers = []string{".git"}
+		}
+		fmt.Fprintf(&buf, "vim.lsp.config(%s, {\n", luaString(s.name))
+		fmt.Fprintf(&buf, "  cmd = %s,\n", luaList(s.cmd))
+		fmt.Fprintf(&buf, "  cmd_env = {\n")
+		fmt.Fprintf(&buf, "    PATH = %s .. vim.env.PATH,\n", luaString(s.dir+string(os.PathListSeparator)))
+		for _, e := range s.env {
+			k, v, _ := strings.Cut(e, "=")
+			fmt.Fprintf(&buf, "    [%s] = %s,\n", luaString(k), luaString(v))
+		}
+		fmt.Fprintf(&buf, "  },\n")
+		fmt.Fprintf(&buf, "  filetypes = %s,\n", luaList(s.filetypes))
+		fmt.Fprintf(&buf, "  root_markers = %s,\n", luaList(rootMarkers))
+		fmt.Fprintf(&buf, "})\n")
+		fmt.Fprintf(&buf, "vim.lsp.enable(%s)\n", luaString(s.name))
+	}
+	return buf.Bytes()
+}
+
+// luaString quotes s as a Lua string literal.
+func luaString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				fmt.Fprintf(&b, `\%03d`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func luaList(list []string) string {
+	quoted := make([]string, 0, len(list))
+	for _, s := range list {
+		quoted = append(quoted, luaString(s))
+	}
+	return "{ " + strings.Join(quoted, ", ") + " }"
+}
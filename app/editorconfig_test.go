@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_EditorConfig(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.installers = map[string]Installer{}
+	for _, name := range []string{"b-ls", "a-ls", "generic-ls", "missing-ls"} {
+		a.installers[name] = newFakeInstaller(a.baseDir, name)
+	}
+	for _, name := range []string{"a-ls", "b-ls", "generic-ls"} {
+		fakeInstall(t, a.installers[name], "1.0.0")
+	}
+	ls := a.installers["a-ls"].base()
+	ls.stdioArgs = []string{"--stdio"}
+	ls.args = []string{"--log=verbose"}
+	ls.runtimeEnv = []string{`A_LS_HOME=C:\a "ls"`}
+	ls.filetypes = []string{"go", "gomod"}
+	ls.rootMarkers = []string{"go.mod"}
+	a.installers["b-ls"].base().filetypes = []string{"typescript"}
+
+	var out bytes.Buffer
+	a.out = &out
+	if err := a.EditorConfig(context.Background(), "neovim"); err != nil {
+		t.Fatal(err)
+	}
+	dir := func(name string) string {
+		return luaString(filepath.Join(a.baseDir, name) + string(os.PathListSeparator))
+	}
+	bin := func(name string) string {
+		return luaString(filepath.Join(a.baseDir, name, name))
+	}
+	want := fmt.Sprintf(`-- generated by lsm editor-config neovim
+
+vim.lsp.config("a-ls", {
+  cmd = { %s, "--log=verbose", "--stdio" },
+  cmd_env = {
+    PATH = %s .. vim.env.PATH,
+    ["A_LS_HOME"] = "C:\\a \"ls\"",
+  },
+  filetypes = { "go", "gomod" },
+  root_markers = { "go.mod" },
+})
+vim.lsp.enable("a-ls")
+
+vim.lsp.config("b-ls", {
+  cmd = { %s },
+  cmd_env = {
+    PATH = %s .. vim.env.PATH,
+  },
+  filetypes = { "typescript" },
+  root_markers = { ".git" },
+})
+vim.lsp.enable("b-ls")
+
+-- generic-ls: no filetypes in the registry
+`, bin("a-ls"), dir("a-ls"), bin("b-ls"), dir("b-ls"))
+	assert.Equal(t, want, out.String())
+
+	assert.EqualError(t, a.EditorConfig(context.Background(), "emacs"), "unsupported editor: emacs")
+}
+
+func Test_luaString(t *testing.T) {
+	assert.Equal(t, `"foo"`, luaString("foo"))
+	assert.Equal(t, `"C:\\Program Files\\\"lsm\""`, luaString(`C:\Program Files\"lsm"`))
+	assert.Equal(t, `"a\nb\tc\000"`, luaString("a\nb\tc\x00"))
+}
+
+func TestRegistry_editorMetadata(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"gopls", "eclipse.jdt.ls", "lemminx"} {
+		i, err := a.getInstaller(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotEmpty(t, i.base().filetypes, name)
+		assert.NotEmpty(t, i.base().rootMarkers, name)
+	}
+}
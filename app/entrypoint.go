@@ -0,0 +1,185 @@
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Entrypoint declares how to launch a language server extracted from an archive.
+// Exactly one of Node, Bin and Jar is set, and the paths are relative to the install directory.
+type Entrypoint struct {
+	// Node is a node script.
+	Node string `json:"node,omitempty" yaml:"node,omitempty"`
+	// Bin maps "os/arch" or "os" to a bundled native executable.
+	Bin map[string]string `json:"bin,omitempty" yaml:"bin,omitempty"`
+	// Jar is a java jar.
+	Jar string `json:"jar,omitempty" yaml:"jar,omitempty"`
+	// Args are passed before the arguments of the wrapper.
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+}
+
+func (e *Entrypoint) validate() error {
+	var n int
+	for _, set := range []bool{e.Node != "", len(e.Bin) != 0, e.Jar != ""} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("entrypoint requires exactly one of node, bin and jar")
+	}
+	return nil
+}
+
+// bin returns the native executable for the platform.
+func (e *Entrypoint) bin(s Support) (string, error) {
+	if b, ok := e.Bin[s.os+"/"+s.arch]; ok {
+		return b, nil
+	}
+	if b, ok := e.Bin[s.os]; ok {
+		return b, nil
+	}
+	return "", fmt.Errorf("entrypoint has no bin for %s/%s", s.os, s.arch)
+}
+
+// command returns the program to launch the entrypoint with and the path of the entrypoint.
+// The program is empty for a native executable.
+func (e *Entrypoint) command(s Support) (prog, path string, err error) {
+	switch {
+	case e.Node != "":
+		return "node", e.Node, nil
+	case e.Jar != "":
+		return "java", e.Jar, nil
+	default:
+		path, err := e.bin(s)
+		return "", path, err
+	}
+}
+
+func quoteArgs(args []string, quote func(string) string) string {
+	var b strings.Builder
+	for _, a := range args {
+		b.WriteString(" ")
+		b.WriteString(quote(a))
+	}
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// batchQuote quotes s as an argument in a batch file.
+// s is quoted for the command line parser of the program first, and then escaped for cmd,
+// which expands %, and interprets ^ and the other special characters outside quotes.
+func batchQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	var backslashes int
+	for _, c := range s {
+		switch c {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, backslashes*2+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, backslashes))
+		}
+		backslashes = 0
+		b.WriteRune(c)
+	}
+	b.WriteString(strings.Repeat(`\`, backslashes*2))
+	b.WriteByte('"')
+	quoted := b.String()
+
+	// an escaped quote in s ends the quotes of cmd, so every special character is escaped by ^
+	escapeAll := strings.ContainsRune(s, '"')
+	b.Reset()
+	for _, c := range quoted {
+		switch {
+		case c == '%':
+			b.WriteString("%%")
+			continue
+		case escapeAll && strings.ContainsRune(`^"&|<>()`, c):
+			b.WriteByte('^')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// shellExports returns the lines that export env of KEY=VALUE.
+func shellExports(env []string) string {
+	var b strings.Builder
+	for _, kv := range env {
+		k := strings.SplitN(kv, "=", 2)
+		b.WriteString("export " + k[0] + "=" + shellQuote(k[1]) + "\n")
+	}
+	return b.String()
+}
+
+// batchSets returns the lines that set env of KEY=VALUE.
+func batchSets(env []string) string {
+	var b strings.Builder
+	for _, kv := range env {
+		b.WriteString(`set "` + strings.ReplaceAll(kv, "%", "%%") + "\"\r\n")
+	}
+	return b.String()
+}
+
+// wrapperScript returns a script that launches the entrypoint relative to the script with the runtime env and args.
+// args are passed after the ones of the entrypoint.
+func (e *Entrypoint) wrapperScript(s Support, args, env []string) (string, error) {
+	prog, path, err := e.command(s)
+	if err != nil {
+		return "", err
+	}
+	args = append(append([]string{}, e.Args...), args...)
+	if s.os == windows {
+		target := `"%~dp0` + strings.ReplaceAll(path, "/", `\`) + `"`
+		switch prog {
+		case "node":
+			target = "node " + target
+		case "java":
+			target = `"%java%" -jar ` + target
+		}
+		return "@echo off\r\n" +
+			"rem Generated by lsm.\r\n" +
+			"setlocal\r\n" +
+			batchSets(env) +
+			"set \"java=java\"\r\n" +
+			"if defined JAVA_HOME set \"java=%JAVA_HOME%\\bin\\java\"\r\n" +
+			target + quoteArgs(args, batchQuote) + " %*\r\n", nil
+	}
+	target := `"$dir/` + path + `"`
+	switch prog {
+	case "node":
+		target = "node " + target
+	case "java":
+		target = `"${JAVA_HOME:+$JAVA_HOME/bin/}java" -jar ` + target
+	}
+	return "#!/bin/sh\n" +
+		"# Generated by lsm.\n" +
+		shellExports(env) +
+		"dir=$(cd \"$(dirname \"$0\")\" && pwd)\n" +
+		"exec " + target + quoteArgs(args, shellQuote) + " \"$@\"\n", nil
+}
+
+// writeWrapper writes the wrapper script of the entrypoint into dir as name.
+func (e *Entrypoint) writeWrapper(dir, name string, s Support, args, env []string) error {
+	script, err := e.wrapperScript(s, args, env)
+	if err != nil {
+		return err
+	}
+	if len(e.Bin) != 0 {
+		bin, _ := e.bin(s)
+		if err := os.Chmod(filepath.Join(dir, filepath.FromSlash(bin)), 0777); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0777)
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntrypoint_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       Entrypoint
+		wantErr bool
+	}{
+		{name: "node", e: Entrypoint{Node: "extension/server.js"}},
+		{name: "bin", e: Entrypoint{Bin: map[string]string{"linux": "extension/bin.linux"}}},
+		{name: "jar", e: Entrypoint{Jar: "extension/server.jar"}},
+		{name: "none", e: Entrypoint{}, wantErr: true},
+		{name: "both", e: Entrypoint{Node: "extension/server.js", Jar: "extension/server.jar"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.e.validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEntrypoint_wrapperScript(t *testing.T) {
+	linux := Support{os: linux, arch: amd64}
+	win := Support{os: windows, arch: amd64}
+	bin := Entrypoint{Bin: map[string]string{"linux/amd64": "extension/bin.linux", "windows": "extension/bin.win32.exe"}}
+
+	script, err := bin.wrapperScript(linux, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, script, `exec "$dir/extension/bin.linux" "$@"`)
+
+	script, err = bin.wrapperScript(win, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, script, `"%~dp0extension\bin.win32.exe" %*`)
+
+	_, err = bin.wrapperScript(Support{os: darwin, arch: amd64}, nil, nil)
+	assert.Error(t, err)
+
+	jar := Entrypoint{Jar: "extension/server.jar", Args: []string{"-v", "it's"}}
+	script, err = jar.wrapperScript(linux, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, script, `exec "${JAVA_HOME:+$JAVA_HOME/bin/}java" -jar "$dir/extension/server.jar" '-v' 'it'\''s' "$@"`)
+
+	script, err = jar.wrapperScript(linux, []string{"--stdio"}, []string{"FOO_LOG=a b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, script, "export FOO_LOG='a b'\n")
+	assert.Contains(t, script, `'-v' 'it'\''s' '--stdio' "$@"`)
+
+	script, err = bin.wrapperScript(win, []string{"--stdio"}, []string{"FOO_LOG=1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, script, "set \"FOO_LOG=1\"\r\n")
+	assert.Contains(t, script, `"%~dp0extension\bin.win32.exe" "--stdio" %*`)
+}
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_batchQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`--stdio`, `"--stdio"`},
+		{`a b`, `"a b"`},
+		{`100%`, `"100%%"`},
+		{`%PATH%`, `"%%PATH%%"`},
+		{`a^b&c`, `"a^b&c"`},
+		{`C:\dir\`, `"C:\dir\\"`},
+		{`say "hi"`, `^"say \^"hi\^"^"`},
+		{`"a&b" ^ %x%`, `^"\^"a^&b\^" ^^ %%x%%^"`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, batchQuote(tt.in), tt.in)
+	}
+}
+
+func TestVSCodeExtensionInstaller_Requires(t *testing.T) {
+	baseDir := t.TempDir()
+	tests := []struct {
+		entrypoint *Entrypoint
+		want       []string
+	}{
+		{nil, noRequires},
+		{&Entrypoint{Node: "server.js"}, []string{"node"}},
+		{&Entrypoint{Jar: "server.jar"}, []string{"java"}},
+		{&Entrypoint{Bin: map[string]string{runtime.GOOS: "server"}}, noRequires},
+	}
+	for _, tt := range tests {
+		i := NewVSCodeExtensionInstaller(baseDir, "foo-ls", "https://example.com/foo.vsix", tt.entrypoint)
+		assert.Equal(t, tt.want, i.Requires())
+	}
+}
+
+func TestVSCodeExtensionInstaller_entrypoint(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	vsix := zipFiles(t, map[string]string{"extension/server/out/server.js": "// server"})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(vsix)
+	}))
+	defer ts.Close()
+
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: vscode-extension
+    url: `+ts.URL+`/foo.vsix
+    entrypoint:
+      node: extension/server/out/server.js
+      args: [--stdio]
+`)
+	assert.Equal(t, []string{"node"}, i.Requires())
+
+	// fake node prints the arguments
+	bin := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(bin, "node"), []byte("#!/bin/sh\necho \"$@\"\n"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if err := a.Install(context.Background(), "foo-ls"); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isInstalled(i))
+
+	cmd := exec.Command(filepath.Join(i.Dir(), i.BinName()), "--verbose")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, filepath.Join(i.Dir(), "extension/server/out/server.js")+" --stdio --verbose", strings.TrimSpace(string(out)))
+}
+
+func TestRegistryEntry_validate_entrypoint(t *testing.T) {
+	_, err := parseRegistry([]byte(`
+servers:
+  - name: foo-ls
+    kind: archive
+    url: https://example.com/foo-ls
+    entrypoint:
+      node: server.js
+`), ".yaml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// executable returns the absolute path of the executable of the installed language server.
+func (a *App) executable(name string) (string, error) {
+	i, err := a.getInstaller(name)
+	if err != nil {
+		return "", err
+	}
+	if i.BinName() == noExecutable {
+		return "", fmt.Errorf("%s has no executable to launch", name)
+	}
+	if !isInstalled(i) {
+		return "", fmt.Errorf("%s is not installed", name)
+	}
+	return filepath.Join(i.Dir(), i.BinName()), nil
+}
+
+// launcherGenerator is implemented by installers whose executable is a launcher generated by lsm.
+// The launcher applies the runtime args and env by itself.
+type launcherGenerator interface {
+	generatesLauncher() bool
+}
+
+func generatesLauncher(i Installer) bool {
+	g, ok := i.(launcherGenerator)
+	return ok && g.generatesLauncher()
+}
+
+// launchEnv returns the environment to launch the language server with.
+// Dir is prepended to PATH so that the executable finds the commands installed with it.
+func launchEnv(i Installer) []string {
+	env := make([]string, 0, len(os.Environ())+1)
+	path := i.Dir()
+	for _, e := range os.Environ() {
+		if k := strings.SplitN(e, "=", 2); strings.EqualFold(k[0], "PATH") && len(k) == 2 {
+			path += string(os.PathListSeparator) + k[1]
+			continue
+		}
+		env = append(env, e)
+	}
+	env = append(env, "PATH="+path)
+	if generatesLauncher(i) {
+		return env
+	}
+	return append(env, i.base().runtimeEnv...)
+}
+
+// launchArgs returns the arguments to launch the language server with, which follow the runtime args.
+func launchArgs(i Installer, args []string) []string {
+	if generatesLauncher(i) {
+		return args
+	}
+	return append(append([]string{}, i.base().args...), args...)
+}
+
+// Which shows the absolute path of the executable of the installed language server.
+func (a *App) Which(ctx context.Context, name string) error {
+	p, err := a.executable(name)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.out, p)
+	return err
+}
+
+// Exec launches the installed language server with args in the current directory.
+// The standard input and outputs are connected to the language server as is,
+// so editors can launch any language server as "lsm exec <name>".
+// If they are the ones of lsm, lsm is replaced with the language server where the platform allows it,
+// so that the language server receives the signals sent to lsm and never outlives it.
+func (a *App) Exec(ctx context.Context, name string, args []string) error {
+	p, err := a.executable(name)
+	if err != nil {
+		return err
+	}
+	i, _ := a.getInstaller(name)
+	args, env := launchArgs(i, args), launchEnv(i)
+	if a.in == os.Stdin && a.out == os.Stdout && a.errOut == os.Stderr {
+		return execProcess(p, args, env)
+	}
+	cmd := exec.CommandContext(ctx, p, args...)
+	cmd.Env = env
+	cmd.Stdin = a.in
+	cmd.Stdout = a.out
+	cmd.Stderr = a.errOut
+	return cmd.Run()
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Which(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := newFakeInstaller(a.baseDir, "fake-ls")
+	a.installers[i.Name()] = i
+	a.installers["vsix-ls"] = NewVSCodeExtensionInstaller(a.baseDir, "vsix-ls", "https://example.com/vsix-ls.vsix", nil)
+	ctx := context.Background()
+
+	assert.EqualError(t, a.Which(ctx, "fake-ls"), "fake-ls is not installed")
+	assert.EqualError(t, a.Which(ctx, "vsix-ls"), "vsix-ls has no executable to launch")
+
+	fakeInstall(t, i, "1.0.0")
+	var buf bytes.Buffer
+	a.out = &buf
+	if err := a.Which(ctx, "fake-ls"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, filepath.Join(a.baseDir, "fake-ls", "fake-ls")+"\n", buf.String())
+}
+
+func TestApp_Exec(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := newFakeInstaller(a.baseDir, "fake-ls")
+	a.installers[i.Name()] = i
+	fakeInstall(t, i, "1.0.0")
+	script := "#!/bin/sh\necho \"$@\"\necho \"$PATH\"\ncat\n"
+	if err := ioutil.WriteFile(filepath.Join(i.Dir(), i.BinName()), []byte(script), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	a.in = strings.NewReader("stdin\n")
+	a.out = &out
+	if err := a.Exec(context.Background(), "fake-ls", []string{"--stdio", "-v"}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "--stdio -v", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], i.Dir()+string(os.PathListSeparator)), lines[1])
+	assert.Equal(t, "stdin", lines[2])
+}
+
+func TestApp_Exec_overrides(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := newFakeInstaller(a.baseDir, "fake-ls")
+	a.installers[i.Name()] = i
+	i.base().args = []string{"--log-level", "debug"}
+	i.base().runtimeEnv = []string{"FAKE_LS_LOG=1"}
+	fakeInstall(t, i, "1.0.0")
+	script := "#!/bin/sh\necho \"$@\"\necho \"$FAKE_LS_LOG\"\n"
+	if err := ioutil.WriteFile(filepath.Join(i.Dir(), i.BinName()), []byte(script), 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	a.in = strings.NewReader("")
+	a.out = &out
+	if err := a.Exec(context.Background(), "fake-ls", []string{"--stdio"}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "--log-level debug --stdio\n1\n", out.String())
+}
+
+func TestApp_Exec_replace(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	// the helper process launches the language server with the standard input and outputs of its own
+	if baseDir := os.Getenv("LSM_TEST_EXEC_BASE_DIR"); baseDir != "" {
+		a, err := New(Options{BaseDir: baseDir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := newFakeInstaller(a.baseDir, "fake-ls")
+		a.installers[i.Name()] = i
+		t.Fatal(a.Exec(context.Background(), "fake-ls", nil))
+	}
+
+	baseDir := t.TempDir()
+	i := newFakeInstaller(baseDir, "fake-ls")
+	fakeInstall(t, i, "1.0.0")
+	if err := ioutil.WriteFile(filepath.Join(i.Dir(), i.BinName()), []byte("#!/bin/sh\necho $$\n"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestApp_Exec_replace$")
+	cmd.Env = append(os.Environ(), "LSM_TEST_EXEC_BASE_DIR="+baseDir)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the language server replaced the helper process
+	assert.Equal(t, strconv.Itoa(cmd.Process.Pid), strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package app
+
+import "syscall"
+
+// execProcess replaces lsm with the language server, which inherits the pid, the standard input and outputs and the signals of lsm.
+func execProcess(path string, args, env []string) error {
+	return syscall.Exec(path, append([]string{path}, args...), env)
+}
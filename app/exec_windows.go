@@ -0,0 +1,40 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// execProcess runs the language server as a child process since Windows cannot replace the process.
+// SIGINT and SIGTERM sent to lsm are forwarded to the language server, which is killed if it cannot receive them,
+// and lsm exits only after the language server does.
+func execProcess(path string, args, env []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if err := cmd.Process.Signal(sig); err != nil {
+					_ = cmd.Process.Kill()
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return cmd.Wait()
+}
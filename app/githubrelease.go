@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const defaultTag = "{{.Version}}"
+
+// GitHubReleaseInstaller downloads an asset of a GitHub release.
+// The tag, the asset name, bin and link are rendered as text/template with templateData,
+// whose OS, Arch and Ext are renamed by the aliases.
+// bin is the path of the executable in the archive, or the file name of a bare executable,
+// and link is the name of the symlink to it, which defaults to the base name of bin.
+type GitHubReleaseInstaller struct {
+	baseInstaller
+
+	name, repo, tag, asset, bin, link string
+	aliases                           Aliases
+	// url replaces the release asset, e.g. with a mirror
+	url string
+}
+
+var _ Installer = (*GitHubReleaseInstaller)(nil)
+
+func NewGitHubReleaseInstaller(baseDir, name, repo, tag, asset, bin, link string, aliases Aliases) *GitHubReleaseInstaller {
+	if tag == "" {
+		tag = defaultTag
+	}
+	i := &GitHubReleaseInstaller{
+		name:    name,
+		repo:    repo,
+		tag:     tag,
+		asset:   asset,
+		bin:     bin,
+		link:    link,
+		aliases: aliases,
+	}
+	i.baseInstaller = newBaseInstaller(filepath.Join(baseDir, name))
+	return i
+}
+
+func (i *GitHubReleaseInstaller) Name() string {
+	return i.name
+}
+
+func (i *GitHubReleaseInstaller) Requires() []string {
+	return noRequires
+}
+
+func (i *GitHubReleaseInstaller) Kind() string {
+	return kindGitHubRelease
+}
+
+// Version returns the requested version without the prefix and suffix of the tag, e.g. "v".
+func (i *GitHubReleaseInstaller) Version() string {
+	return i.trimTag(i.versionOr(versionUnSpecified))
+}
+
+// trimTag returns the version in the tag, or the tag itself if the tag template has no version in it.
+func (i *GitHubReleaseInstaller) trimTag(tag string) string {
+	n := strings.Index(i.tag, defaultTag)
+	if n < 0 {
+		return tag
+	}
+	prefix, suffix := i.tag[:n], i.tag[n+len(defaultTag):]
+	if strings.Contains(prefix+suffix, "{{") {
+		return tag
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(tag, prefix), suffix)
+}
+
+func (i *GitHubReleaseInstaller) BinName() string {
+	d := i.data(i.platform())
+	if i.link != "" {
+		link, err := renderTemplate(i.link, d)
+		if err != nil {
+			return noExecutable
+		}
+		return link
+	}
+	bin, err := renderTemplate(i.bin, d)
+	if err != nil {
+		return noExecutable
+	}
+	return path.Base(bin)
+}
+
+// data returns templateData for the platform with the aliases applied.
+func (i *GitHubReleaseInstaller) data(s Support) templateData {
+	d := i.templateData(i.Name())
+	d.Version = i.Version()
+	return d.withAliases(s, i.aliases)
+}
+
+// release returns the rendered tag and asset name for the platform.
+func (i *GitHubReleaseInstaller) release(s Support) (tag, asset string, err error) {
+	if i.Version() == versionUnSpecified {
+		return "", "", fmt.Errorf("%s: version is required", i.Name())
+	}
+	d := i.data(s)
+	if tag, err = renderTemplate(i.tag, d); err != nil {
+		return "", "", err
+	}
+	if asset, err = renderTemplate(i.asset, d); err != nil {
+		return "", "", err
+	}
+	return tag, asset, nil
+}
+
+func (i *GitHubReleaseInstaller) setURL(u string) {
+	i.url = u
+}
+
+// endpoints returns the endpoints of the registry client, or the default ones if none.
+func (i *GitHubReleaseInstaller) endpoints() Endpoints {
+	if i.registry != nil {
+		return i.registry.endpoints
+	}
+	return DefaultEndpoints
+}
+
+// artifactURL returns the download URL of the asset, which is determined by the tag and the asset name without the GitHub API.
+func (i *GitHubReleaseInstaller) artifactURL(s Support) (string, error) {
+	if i.url != "" {
+		return renderTemplate(i.url, i.data(s))
+	}
+	tag, asset, err := i.release(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/releases/download/%s/%s", i.endpoints().GitHub, i.repo, url.PathEscape(tag), url.PathEscape(asset)), nil
+}
+
+type githubAsset struct {
+	Name string `json:"name"`
+}
+
+// assetNames lists the assets of the release with the GitHub API.
+func (i *GitHubReleaseInstaller) assetNames(ctx context.Context, c *registryClient, tag string) ([]string, error) {
+	var v struct {
+		Assets []githubAsset `json:"assets"`
+	}
+	u := fmt.Sprintf("%s/repos/%s/releases/tags/%s", c.endpoints.GitHubAPI, i.repo, url.PathEscape(tag))
+	if err := c.getJSON(ctx, u, &v); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(v.Assets))
+	for _, a := range v.Assets {
+		names = append(names, a.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// diagnoseNotFound explains a 404 of the asset with the assets available in the release, if the GitHub API tells them.
+func (i *GitHubReleaseInstaller) diagnoseNotFound(ctx context.Context, s Support, err error) error {
+	if i.url != "" || i.registry == nil {
+		return err
+	}
+	tag, asset, rerr := i.release(s)
+	if rerr != nil {
+		return err
+	}
+	names, lerr := i.assetNames(ctx, i.registry, tag)
+	if lerr != nil {
+		i.logger().Printf("failed to list the assets of %s %s: %v", i.repo, tag, lerr)
+		return err
+	}
+	for _, name := range names {
+		if name == asset {
+			return err
+		}
+	}
+	return fmt.Errorf("%s %s has no asset %s, available assets: %s", i.repo, tag, asset, strings.Join(names, ", "))
+}
+
+func (i *GitHubReleaseInstaller) Install(ctx context.Context, dir string) error {
+	p := i.platform()
+	u, err := i.artifactURL(p)
+	if err != nil {
+		return err
+	}
+	bin, err := renderTemplate(i.bin, i.data(p))
+	if err != nil {
+		return err
+	}
+	if err := i.installArtifact(ctx, dir, u, bin, i.BinName()); err != nil {
+		if isNotFound(err) {
+			return i.diagnoseNotFound(ctx, p, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (i *GitHubReleaseInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	owner, repo := splitRepo(i.repo)
+	tag, err := c.githubLatest(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return i.trimTag(tag), nil
+}
+
+// splitRepo splits "owner/repo".
+func splitRepo(s string) (owner, repo string) {
+	owner, repo, _ = strings.Cut(s, "/")
+	return owner, repo
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newGitHubTestServer serves the releases of foo/foo-ls like the GitHub API under /github,
+// and the assets under /github.com, and counts the requests to the API.
+func newGitHubTestServer(t *testing.T, assets map[string][]byte) (*httptest.Server, *int32) {
+	t.Helper()
+	var apiCalls int32
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	mux.HandleFunc("/github/repos/foo/foo-ls/releases/tags/v1.2.0", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		var v struct {
+			Assets []githubAsset `json:"assets"`
+		}
+		for name := range assets {
+			v.Assets = append(v.Assets, githubAsset{Name: name})
+		}
+		_ = json.NewEncoder(w).Encode(v)
+	})
+	mux.HandleFunc("/github/repos/foo/foo-ls/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&apiCalls, 1)
+		_, _ = w.Write([]byte(`{"tag_name": "v1.3.0"}`))
+	})
+	for name, b := range assets {
+		b := b
+		mux.HandleFunc("/github.com/foo/foo-ls/releases/download/v1.2.0/"+name, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(b)
+		})
+	}
+	return ts, &apiCalls
+}
+
+func newGitHubReleaseTestApp(t *testing.T, ts *httptest.Server, entry string) (*App, Installer) {
+	t.Helper()
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: github-release
+    repo: foo/foo-ls
+    tag: v{{.Version}}
+    version: 1.2.0
+`+entry)
+	a.SetEndpoints(EndpointsFor(ts.URL))
+	return a, i
+}
+
+func TestGitHubReleaseInstaller(t *testing.T) {
+	if isWindows {
+		t.Skip("shell script")
+	}
+	asset := "foo-ls_myos_" + runtime.GOARCH + ".zip"
+	ts, apiCalls := newGitHubTestServer(t, map[string][]byte{
+		asset:                zipFiles(t, map[string]string{"foo-ls_1.2.0/bin/foo-ls": "#!/bin/sh\n"}),
+		"foo-ls_plan9_amd64": []byte("#!/bin/rc\n"),
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		a, i := newGitHubReleaseTestApp(t, ts, `
+    asset: foo-ls_{{.OS}}_{{.Arch}}{{.Ext}}
+    aliases:
+      os:
+        `+runtime.GOOS+`: myos
+      ext:
+        plan9: .tar.gz
+        default: .zip
+    bin: foo-ls_{{.Version}}/bin/foo-ls
+`)
+		assert.Equal(t, "foo-ls", i.BinName())
+		if err := a.Install(context.Background(), "foo-ls"); err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, isInstalled(i))
+		link, err := os.Readlink(filepath.Join(i.Dir(), "foo-ls"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, filepath.Join("foo-ls_1.2.0", "bin", "foo-ls"), link)
+		r, err := readReceipt(i.Dir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "1.2.0", r.Version)
+		assert.Equal(t, ts.URL+"/github.com/foo/foo-ls/releases/download/v1.2.0/"+asset, r.Source)
+		assert.Equal(t, int32(0), atomic.LoadInt32(apiCalls), "the GitHub API should not be used")
+	})
+
+	t.Run("executable", func(t *testing.T) {
+		a, i := newGitHubReleaseTestApp(t, ts, `
+    asset: foo-ls_plan9_amd64
+    bin: foo-ls_plan9_amd64
+    link: foo-ls
+`)
+		if err := a.Install(context.Background(), "foo-ls@v1.2.0"); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(i.Dir(), "foo-ls"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "#!/bin/rc\n", string(b))
+	})
+
+	t.Run("no asset", func(t *testing.T) {
+		a, _ := newGitHubReleaseTestApp(t, ts, `
+    asset: foo-ls_unknown.zip
+    bin: foo-ls
+`)
+		err := a.Install(context.Background(), "foo-ls")
+		assert.EqualError(t, err, "foo/foo-ls v1.2.0 has no asset foo-ls_unknown.zip, available assets: foo-ls_myos_"+runtime.GOARCH+".zip, foo-ls_plan9_amd64")
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		a, i := newGitHubReleaseTestApp(t, ts, `
+    asset: foo-ls
+    bin: foo-ls
+`)
+		v, err := i.(latestVersioner).LatestVersion(context.Background(), a.registryClient())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "1.3.0", v)
+	})
+}
+
+func TestGitHubReleaseInstaller_artifactURL(t *testing.T) {
+	i := NewGitHubReleaseInstaller(t.TempDir(), "rust-analyzer", "rust-analyzer/rust-analyzer", "", "rust-analyzer-{{.OS}}{{.Exe}}", "rust-analyzer{{.Exe}}", "",
+		Aliases{OS: map[string]string{darwin: "mac"}})
+	i.SetVersion("2020-05-11")
+	tests := []struct {
+		support Support
+		want    string
+	}{
+		{Support{os: darwin, arch: amd64}, "https://github.com/rust-analyzer/rust-analyzer/releases/download/2020-05-11/rust-analyzer-mac"},
+		{Support{os: windows, arch: amd64}, "https://github.com/rust-analyzer/rust-analyzer/releases/download/2020-05-11/rust-analyzer-windows.exe"},
+	}
+	for _, tt := range tests {
+		got, err := i.artifactURL(tt.support)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestRegistryEntry_validate_githubRelease(t *testing.T) {
+	tests := []struct {
+		name, registry string
+	}{
+		{"no repo", `{"servers": [{"name": "foo-ls", "kind": "github-release", "asset": "foo", "bin": "foo"}]}`},
+		{"invalid repo", `{"servers": [{"name": "foo-ls", "kind": "github-release", "repo": "foo/bar/baz", "asset": "foo", "bin": "foo"}]}`},
+		{"no asset", `{"servers": [{"name": "foo-ls", "kind": "github-release", "repo": "foo/bar", "bin": "foo"}]}`},
+		{"no bin", `{"servers": [{"name": "foo-ls", "kind": "github-release", "repo": "foo/bar", "asset": "foo"}]}`},
+		{"other kind", `{"servers": [{"name": "foo-ls", "kind": "archive", "url": "https://example.com/foo", "repo": "foo/bar"}]}`},
+	}
+	for _, tt := range tests {
+		_, err := parseRegistry([]byte(tt.registry), ".json")
+		assert.Error(t, err, tt.name)
+	}
+}
+
+func TestBuiltinRegistry_terraformLS(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := a.getInstaller("terraform-ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.IsType(t, &GitHubReleaseInstaller{}, i)
+	assert.Equal(t, kindGitHubRelease, i.Kind())
+	assert.Contains(t, i.Supports(), Support{os: darwin, arch: arm64})
+	u, err := i.(artifacter).artifactURL(Support{os: darwin, arch: arm64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://github.com/hashicorp/terraform-ls/releases/download/v0.29.0/terraform-ls_0.29.0_darwin_arm64.zip", u)
+	assert.Equal(t, "https://releases.hashicorp.com/terraform-ls/{{.Version}}/terraform-ls_{{.Version}}_SHA256SUMS", i.base().checksums.url)
+}
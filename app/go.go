@@ -2,33 +2,43 @@ package app
 
 import (
 	"context"
-	"os"
+	"encoding/json"
+	"fmt"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"unicode"
+
+	"github.com/Masterminds/semver/v3"
 )
 
 type GoInstaller struct {
 	baseInstaller
 
-	goPath, binName string
-	cgo             bool
+	name, goPath, binName string
+	cgo                   bool
+	// module is the module providing goPath, used to look up the module proxy
+	module string
+	// modCache is the module cache shared by the Go installers, or an empty string if none
+	modCache string
 }
 
 var _ Installer = (*GoInstaller)(nil)
 
-func NewGoInstaller(baseDir, goPath, binName string, cgo bool) *GoInstaller {
+func NewGoInstaller(baseDir, name, goPath, binName string, cgo bool) *GoInstaller {
 	i := &GoInstaller{
+		name:    name,
 		goPath:  goPath,
 		binName: binName,
 		cgo:     cgo,
+		module:  goPath,
 	}
 	i.baseInstaller = newBaseInstaller(filepath.Join(baseDir, i.Name()))
 	return i
 }
 
 func (i *GoInstaller) Name() string {
-	return i.binName
+	return i.name
 }
 
 func (i *GoInstaller) BinName() string {
@@ -38,44 +48,183 @@ func (i *GoInstaller) BinName() string {
 	return i.binName
 }
 
-func (i *GoInstaller) Version() string {
-	return versionUnSpecified
+func (i *GoInstaller) setModCache(dir string) {
+	i.modCache = dir
 }
 
-func (i *GoInstaller) cmdRun(ctx context.Context, name string, args ...string) error {
+func (i *GoInstaller) cmdRun(ctx context.Context, dir, name string, args ...string) error {
 	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = i.Dir()
-	cmd.Env = append(os.Environ(), "GOPATH="+i.Dir(), "GOBIN="+i.Dir(), "GO111MODULE=on")
+	cmd.Dir = dir
+	// -modcacherw keeps the module cache removable
+	env := i.environ()
+	cmd.Env = append(env, "GOBIN="+dir, "GO111MODULE=on", "GOFLAGS="+strings.TrimSpace(getenv(env, "GOFLAGS")+" -modcacherw"))
+	if i.modCache != "" {
+		cmd.Env = append(cmd.Env, "GOMODCACHE="+i.modCache)
+	} else {
+		cmd.Env = append(cmd.Env, "GOPATH="+dir)
+	}
 	cmd.Stdout = i.stdout
 	cmd.Stderr = i.stderr
 	return cmd.Run()
 }
 
-func (i *GoInstaller) Install(ctx context.Context) error {
-	if err := i.cmdRun(ctx, "go", "get", i.goPath); err != nil {
-		return err
+func (i *GoInstaller) packageSpec() string {
+	if v := i.Version(); v != versionUnSpecified {
+		return i.goPath + "@" + v
+	}
+	return i.goPath
+}
+
+func (i *GoInstaller) Install(ctx context.Context, dir string) error {
+	pkg := i.packageSpec()
+	target := pkg
+	if i.Version() == versionUnSpecified {
+		target += "@latest"
 	}
-	if err := i.cmdRun(ctx, "go", "clean", "-modcache"); err != nil {
+	if err := i.cmdRun(ctx, dir, "go", i.withInstallArgs([]string{"install"}, target)...); err != nil {
 		return err
 	}
+	if i.modCache == "" {
+		// the module cache is in the install directory
+		if err := i.cmdRun(ctx, dir, "go", "clean", "-modcache"); err != nil {
+			return err
+		}
+	}
+	i.record("go:"+pkg, "")
+	if v, err := i.installedVersion(ctx, dir); err == nil {
+		i.resolvedVersion = v
+	} else {
+		i.logger().Println(err)
+	}
 	return nil
 }
 
+// installedVersion reads the main module version embedded in the binary in dir.
+func (i *GoInstaller) installedVersion(ctx context.Context, dir string) (string, error) {
+	out, err := exec.CommandContext(ctx, "go", "version", "-m", filepath.Join(dir, i.BinName())).Output()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[0] == "mod" {
+			return fields[2], nil
+		}
+	}
+	return "", fmt.Errorf("module version not found in %s", i.BinName())
+}
+
 func (i *GoInstaller) Requires() []string {
 	return []string{"go"}
 }
 
+func (i *GoInstaller) Kind() string {
+	return kindGo
+}
+
 func (i *GoInstaller) RequireHook(ctx context.Context) error {
-	if !i.cgo {
+	if i.cgo {
+		if _, err := lookCC(ctx); err != nil {
+			return err
+		}
+	}
+	return i.checkGoVersion(ctx)
+}
+
+// goEnv returns the go environment variables.
+func goEnv(ctx context.Context, keys ...string) (map[string]string, error) {
+	out, err := exec.CommandContext(ctx, "go", append([]string{"env", "-json"}, keys...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(keys))
+	if err := json.Unmarshal(out, &env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// moduleProxy returns the first module proxy URL in GOPROXY, or an empty string if none.
+func moduleProxy(goproxy string) string {
+	for _, p := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		if strings.HasPrefix(p, "https://") || strings.HasPrefix(p, "http://") {
+			return strings.TrimSuffix(p, "/")
+		}
+	}
+	return ""
+}
+
+// parseGoVersion parses a go version such as "go1.21.3", "1.18" or "1.21rc1".
+func parseGoVersion(v string) (*semver.Version, error) {
+	v = strings.TrimPrefix(v, "go")
+	if n := strings.IndexFunc(v, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) }); n >= 0 {
+		v = v[:n]
+	}
+	return semver.NewVersion(v)
+}
+
+// toolchainSwitch is the first go version that switches to a newer toolchain as go.mod requires.
+var toolchainSwitch = semver.MustParse("1.21")
+
+// checkGoVersion checks that the go toolchain satisfies the go directive of the module via the module proxy.
+// It fails only if the toolchain is too old, and the check is skipped with a warning if the go directive is unknown,
+// e.g. offline, for a private module or if the module is not found by the path.
+func (i *GoInstaller) checkGoVersion(ctx context.Context) error {
+	env, err := goEnv(ctx, "GOVERSION", "GOPROXY", "GOTOOLCHAIN")
+	if err != nil {
+		i.logger().Printf("%s: skipped the go version check: go env: %v", i.Name(), err)
+		return nil
+	}
+	proxy := moduleProxy(env["GOPROXY"])
+	if proxy == "" {
+		return nil
+	}
+	have, err := parseGoVersion(env["GOVERSION"])
+	if err != nil {
+		i.logger().Printf("%s: unknown go version %q: %v", i.Name(), env["GOVERSION"], err)
+		return nil
+	}
+
+	c := &registryClient{client: i.httpClient(), endpoints: Endpoints{GoProxy: proxy}, retry: i.retry, logger: i.logger()}
+	version := i.Version()
+	if version == versionUnSpecified {
+		if version, err = c.goLatest(ctx, i.module); err != nil {
+			i.logger().Printf("%s: skipped the go version check: %v", i.Name(), err)
+			return nil
+		}
+	}
+	directive, err := c.goModGoVersion(ctx, i.module, version)
+	if err != nil {
+		i.logger().Printf("%s: skipped the go version check: %v", i.Name(), err)
 		return nil
 	}
+	if directive == "" {
+		return nil
+	}
+	want, err := parseGoVersion(directive)
+	if err != nil {
+		i.logger().Printf("%s: skipped the go version check: %s@%s: invalid go directive %q: %v", i.Name(), i.module, version, directive, err)
+		return nil
+	}
+	if !have.LessThan(want) {
+		return nil
+	}
+	if !have.LessThan(toolchainSwitch) && strings.HasSuffix(env["GOTOOLCHAIN"], "auto") {
+		// go downloads the toolchain
+		return nil
+	}
+	return fmt.Errorf("%s@%s requires go %s or later, but go is %s", i.module, version, directive, env["GOVERSION"])
+}
+
+// lookCC returns the C compiler used by cgo.
+func lookCC(ctx context.Context) (string, error) {
 	out, err := exec.CommandContext(ctx, "go", "env", "CC").Output()
 	if err != nil {
-		return err
+		return "", err
 	}
 	cc := strings.TrimSpace(string(out))
 	if _, err := exec.LookPath(cc); err != nil {
-		return err
+		return "", err
 	}
-	return nil
+	return cc, nil
 }
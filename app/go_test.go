@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseGoVersion(t *testing.T) {
+	tests := []struct {
+		v    string
+		want string
+	}{
+		{"go1.18", "1.18.0"},
+		{"go1.21.3", "1.21.3"},
+		{"1.21rc1", "1.21.0"},
+		{"1.16", "1.16.0"},
+	}
+	for _, tt := range tests {
+		got, err := parseGoVersion(tt.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, tt.want, got.String(), tt.v)
+	}
+}
+
+func Test_moduleProxy(t *testing.T) {
+	assert.Equal(t, "https://proxy.golang.org", moduleProxy("https://proxy.golang.org,direct"))
+	assert.Equal(t, "https://goproxy.example.com", moduleProxy("direct|https://goproxy.example.com/"))
+	assert.Equal(t, "", moduleProxy("off"))
+}
+
+func TestGoInstaller_RequireHook(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/example.com/!foo/@latest":
+			fmt.Fprint(w, `{"Version":"v1.1.0"}`)
+		case "/example.com/!foo/@v/v1.0.0.mod":
+			fmt.Fprint(w, "module example.com/Foo\n\ngo 1.16\n")
+		case "/example.com/!foo/@v/v1.1.0.mod":
+			fmt.Fprint(w, "module example.com/Foo\n\ngo 999.0\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	t.Setenv("GOPROXY", ts.URL+",direct")
+	t.Setenv("GOTOOLCHAIN", "local")
+
+	i := NewGoInstaller(t.TempDir(), "foo-ls", "example.com/Foo/cmd/foo-ls", "foo-ls", false)
+	i.module = "example.com/Foo"
+	ctx := context.Background()
+
+	i.SetVersion("v1.0.0")
+	assert.NoError(t, i.RequireHook(ctx))
+
+	// latest
+	i.SetVersion("")
+	err := i.RequireHook(ctx)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "example.com/Foo@v1.1.0 requires go 999.0 or later")
+	}
+
+	// unknown version, not a definite result
+	i.SetVersion("v0.1.0")
+	assert.NoError(t, i.RequireHook(ctx))
+
+	// module proxy failure, not a definite result
+	t.Setenv("GOPROXY", "http://127.0.0.1:1")
+	i.SetVersion("")
+	assert.NoError(t, i.RequireHook(ctx))
+
+	// no module proxy
+	t.Setenv("GOPROXY", "direct")
+	i.SetVersion("")
+	assert.NoError(t, i.RequireHook(ctx))
+}
@@ -2,13 +2,19 @@ package app
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/cheggaaa/pb/v3"
 	"github.com/mattn/go-colorable"
@@ -26,6 +32,7 @@ const (
 	amd64 = "amd64"
 	_386  = "386"
 	arm   = "arm"
+	arm64 = "arm64"
 
 	appName = "lsm"
 	servers = "servers"
@@ -37,12 +44,6 @@ const (
 // readonly
 var (
 	noRequires []string
-
-	generalSupports = []Support{
-		{os: darwin, arch: amd64},
-		{os: linux, arch: amd64},
-		{os: windows, arch: amd64},
-	}
 )
 
 type Installer interface {
@@ -53,8 +54,13 @@ type Installer interface {
 	RequireHook(ctx context.Context) error
 	Supports() []Support
 	Version() string
-	Install(ctx context.Context) error
+	Kind() string
+	// Install installs the language server into dir, a staging directory that replaces Dir after the installation.
+	Install(ctx context.Context, dir string) error
 	SetWriter(w io.Writer)
+	SetVersion(version string)
+
+	base() *baseInstaller
 }
 
 type Support struct {
@@ -62,8 +68,45 @@ type Support struct {
 }
 
 type baseInstaller struct {
-	dir            string
+	dir     string
+	version string
+	// defaultVersion is the version declared in a registry, used if no version is requested
+	defaultVersion string
+	supports       []Support
 	stdout, stderr io.Writer
+	// quiet disables progress bars, e.g. when the output is buffered
+	quiet bool
+
+	checksums          checksums
+	insecureSkipVerify bool
+	requireChecksum    bool
+
+	// cache is the download cache consulted by Download, if any
+	cache *downloadCache
+	// noCache disables the download cache for URLs whose contents change, e.g. "latest"
+	noCache bool
+	// target overrides the host platform, e.g. to fetch artifacts for another platform
+	target Support
+	// env is added to the environment of the commands run by Install after proxyEnv
+	env, proxyEnv []string
+	// installArgs are added to the install command of the package manager
+	installArgs []string
+	// args and runtimeEnv are applied when the language server is launched
+	args, runtimeEnv []string
+	// stdioArgs make the language server speak LSP over stdio
+	stdioArgs []string
+	// filetypes and rootMarkers tell editors when to launch the language server
+	filetypes, rootMarkers []string
+	// registry is the client for the registry APIs used during installation, if any
+	registry *registryClient
+	// retry is the policy of downloads
+	retry retryPolicy
+
+	// data is the templateData of the installer being installed
+	data templateData
+
+	// recorded by Install for the receipt
+	resolvedVersion, source, checksum string
 }
 
 func newBaseInstaller(dir string) baseInstaller {
@@ -77,63 +120,280 @@ func (i *baseInstaller) RequireHook(ctx context.Context) error {
 }
 
 func (i *baseInstaller) Supports() []Support {
-	return []Support{}
+	return i.supports
+}
+
+func (i *baseInstaller) Version() string {
+	return i.versionOr(versionUnSpecified)
+}
+
+func (i *baseInstaller) SetVersion(version string) {
+	i.version = version
+}
+
+// versionOr returns the requested version, or the default version of the registry or def if no version is requested.
+func (i *baseInstaller) versionOr(def string) string {
+	if i.version != versionUnSpecified {
+		return i.version
+	}
+	if i.defaultVersion != versionUnSpecified {
+		return i.defaultVersion
+	}
+	return def
+}
+
+func (i *baseInstaller) base() *baseInstaller {
+	return i
+}
+
+// record records where the installed files come from.
+func (i *baseInstaller) record(source, checksum string) {
+	i.source = source
+	i.checksum = checksum
+}
+
+func (i *baseInstaller) resetRecord() {
+	i.resolvedVersion, i.source, i.checksum = "", "", ""
+}
+
+func (i *baseInstaller) templateData(name string) templateData {
+	return templateData{Name: name, Version: i.Version()}.withPlatform(i.platform())
+}
+
+// platform returns the platform to install language servers for.
+func (i *baseInstaller) platform() Support {
+	if i.target != (Support{}) {
+		return i.target
+	}
+	return hostPlatform()
+}
+
+func hostPlatform() Support {
+	return Support{os: runtime.GOOS, arch: runtime.GOARCH}
+}
+
+// newTemplateData returns templateData of the installer, which may override Version.
+func newTemplateData(i Installer) templateData {
+	d := i.base().templateData(i.Name())
+	d.Version = i.Version()
+	return d
 }
 
 func (i *baseInstaller) SetWriter(w io.Writer) {
 	i.stderr = w
 }
 
+// setOutput redirects all the output of the installer to w without progress bars.
+func (i *baseInstaller) setOutput(w io.Writer) {
+	i.stdout = w
+	i.stderr = w
+	i.quiet = true
+}
+
+// logger logs to the error output of the installer, which is buffered per language server in parallel installations.
+func (i *baseInstaller) logger() *log.Logger {
+	if i.stderr == nil {
+		return log.Default()
+	}
+	return log.New(i.stderr, "", log.Flags())
+}
+
 func (i *baseInstaller) Dir() string {
 	return i.dir
 }
 
 func (i *baseInstaller) Download(req *http.Request, archive string) error {
-	resp, err := http.DefaultClient.Do(req)
+	if i.cache != nil && !i.noCache {
+		u := req.URL.String()
+		if digest, ok := i.cache.get(u, archive); ok {
+			err := i.verifyDownload(req, archive, digest)
+			if !errors.Is(err, errChecksumMismatch) {
+				return err
+			}
+			// the cached file may be a different build published under the same URL
+			i.logger().Printf("download cache: %v, downloading %s again", err, u)
+			if err := i.cache.evict(u); err != nil {
+				i.logger().Println(err)
+			}
+		}
+	}
+
+	if err := os.Remove(archive); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := i.retry.run(req.Context(), i.logger(), func() error {
+		return i.downloadAttempt(req, archive)
+	}); err != nil {
+		return err
+	}
+	digest, err := fileDigest(archive)
 	if err != nil {
 		return err
 	}
+	if err := i.verifyDownload(req, archive, digest); err != nil {
+		return err
+	}
+	if i.cache != nil && !i.noCache {
+		if err := i.cache.putFile(req.URL.String(), archive); err != nil {
+			i.logger().Println(err)
+		}
+	}
+	return nil
+}
+
+// verifyDownload verifies the checksum of the downloaded file and records it, or removes it.
+func (i *baseInstaller) verifyDownload(req *http.Request, archive, digest string) error {
+	if err := i.verifyChecksum(req.Context(), req.URL.String(), digest); err != nil {
+		if err := os.Remove(archive); err != nil {
+			i.logger().Println(err)
+		}
+		return err
+	}
+	i.record(req.URL.String(), digest)
+	return nil
+}
+
+// downloadAttempt downloads the URL of the request into the file, resuming a partial file with a Range request.
+func (i *baseInstaller) downloadAttempt(req *http.Request, name string) error {
+	var offset int64
+	if info, err := os.Stat(name); err == nil {
+		offset = info.Size()
+	}
+	ctx, w, stop := i.retry.watch(req.Context())
+	defer stop()
+	req = req.Clone(ctx)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	u := req.URL.String()
+	resp, err := i.httpClient().Do(req)
+	if err != nil {
+		return w.transportError(req.Context(), u, err)
+	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		b, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	switch resp.StatusCode {
+	case http.StatusOK:
+		offset = 0
+	case http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			return retryable(fmt.Errorf("GET %s: unexpected Content-Range %q", u, resp.Header.Get("Content-Range")))
+		}
+		flag = os.O_WRONLY | os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		return retryable(fmt.Errorf("GET %s: range of the partial download is not satisfiable", u))
+	default:
+		if err := i.retry.checkStatus(resp); err != nil {
 			return err
 		}
-		return fmt.Errorf("invalid status code: %v, body=%v", resp.StatusCode, string(b))
+		return fmt.Errorf("GET %s: unexpected status code: %v", u, resp.StatusCode)
 	}
 
-	f, err := os.Create(archive)
+	f, err := os.OpenFile(name, flag, 0666)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
+	r := w.reader(resp.Body)
+	if !i.quiet {
+		total := resp.ContentLength
+		if total >= 0 {
+			total += offset
+		}
+		bar := pb.Full.Start64(total)
+		defer bar.Finish()
+		bar.SetWriter(i.stderr)
+		bar.SetCurrent(offset)
+		r = bar.NewProxyReader(r)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		return w.transportError(req.Context(), u, err)
+	}
+	return f.Close()
+}
 
-	bar := pb.Full.Start64(resp.ContentLength)
-	defer bar.Finish()
-	bar.SetWriter(i.stderr)
-	pr := bar.NewProxyReader(resp.Body)
-	if _, err := io.Copy(f, pr); err != nil {
-		return err
+// contentRangeStart returns the first byte position of a Content-Range header such as "bytes 100-199/200".
+func contentRangeStart(s string) (int64, bool) {
+	s = strings.TrimPrefix(s, "bytes ")
+	n := strings.Index(s, "-")
+	if n < 0 {
+		return 0, false
 	}
-	return nil
+	start, err := strconv.ParseInt(s[:n], 10, 64)
+	return start, err == nil
+}
+
+// fileDigest returns the digest of the file.
+func fileDigest(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// withInstallArgs returns args followed by installArgs and operands.
+func (i *baseInstaller) withInstallArgs(args []string, operands ...string) []string {
+	v := make([]string, 0, len(args)+len(i.installArgs)+len(operands))
+	v = append(v, args...)
+	v = append(v, i.installArgs...)
+	return append(v, operands...)
+}
+
+// httpClient returns the client for downloads.
+func (i *baseInstaller) httpClient() *http.Client {
+	if i.registry != nil {
+		return i.registry.client
+	}
+	return http.DefaultClient
+}
+
+// environ returns the environment of the commands run by Install.
+func (i *baseInstaller) environ() []string {
+	env := os.Environ()
+	env = append(env, i.proxyEnv...)
+	return append(env, i.env...)
+}
+
+// getenv returns the value of the last key in env, as exec.Cmd does.
+func getenv(env []string, key string) string {
+	for n := len(env) - 1; n >= 0; n-- {
+		if strings.HasPrefix(env[n], key+"=") {
+			return env[n][len(key)+1:]
+		}
+	}
+	return ""
 }
 
-func (i *baseInstaller) CmdRun(ctx context.Context, name string, args ...string) error {
+func (i *baseInstaller) CmdRun(ctx context.Context, dir, name string, args ...string) error {
 	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Dir = i.Dir()
+	cmd.Dir = dir
+	cmd.Env = i.environ()
 	cmd.Stdout = i.stdout
 	cmd.Stderr = i.stderr
 	return cmd.Run()
 }
 
+// Extract extracts the archive into the directory it is in, and removes it.
 func (i *baseInstaller) Extract(ctx context.Context, path string) error {
 	defer func() {
 		if err := os.Remove(path); err != nil {
-			log.Println(err)
+			i.logger().Println(err)
 		}
 	}()
-	return archiver.Unarchive(path, i.Dir())
+	return archiver.Unarchive(path, filepath.Dir(path))
 }
 
 func (i *baseInstaller) ExtractWithDownload(req *http.Request, path string) error {
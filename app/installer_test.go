@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -213,10 +214,66 @@ func newInstallerTestHelper(t *testing.T) *installerTestHelper {
 			t.Fatal(err)
 		}
 	})
-	a, err := New(tmp)
+	// keep the download cache next to baseDir in tmp
+	baseDir := filepath.Join(tmp, servers)
+	a, err := New(Options{BaseDir: baseDir})
 	if err != nil {
 		t.Fatal(err)
 	}
-	a.baseDir = tmp
+	a.baseDir = baseDir
 	return &installerTestHelper{t: t, a: a}
 }
+
+// fakeInstaller writes an executable script instead of installing a real language server.
+type fakeInstaller struct {
+	baseInstaller
+
+	name     string
+	err      error
+	noBin    bool
+	requires []string
+	// installedInto is the dir passed to the last Install
+	installedInto string
+}
+
+var _ Installer = (*fakeInstaller)(nil)
+
+func newFakeInstaller(baseDir, name string) *fakeInstaller {
+	i := &fakeInstaller{name: name}
+	i.baseInstaller = newBaseInstaller(filepath.Join(baseDir, name))
+	i.SetWriter(ioutil.Discard)
+	return i
+}
+
+func (i *fakeInstaller) Name() string {
+	return i.name
+}
+
+func (i *fakeInstaller) BinName() string {
+	return i.name
+}
+
+func (i *fakeInstaller) Requires() []string {
+	return i.requires
+}
+
+func (i *fakeInstaller) Kind() string {
+	return "fake"
+}
+
+func (i *fakeInstaller) Install(ctx context.Context, dir string) error {
+	fmt.Fprintf(i.stderr, "installing %s\n", i.name)
+	i.installedInto = dir
+	if i.err != nil {
+		return i.err
+	}
+	i.record("fake:"+i.name, "")
+	if i.noBin {
+		return nil
+	}
+	return ioutil.WriteFile(filepath.Join(dir, i.BinName()), []byte("#!/bin/sh\n"), 0777)
+}
+
+func (i *fakeInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.npmLatest(ctx, i.name)
+}
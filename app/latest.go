@@ -0,0 +1,240 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Endpoints are the base URLs used to look up the latest versions of language servers.
+// GitHub is the base URL of the release assets.
+type Endpoints struct {
+	NpmRegistry string
+	PyPI        string
+	GoProxy     string
+	GitHubAPI   string
+	GitHub      string
+	Crates      string
+}
+
+// DefaultEndpoints are the public registries.
+var DefaultEndpoints = Endpoints{
+	NpmRegistry: "https://registry.npmjs.org",
+	PyPI:        "https://pypi.org/pypi",
+	GoProxy:     "https://proxy.golang.org",
+	GitHubAPI:   "https://api.github.com",
+	GitHub:      "https://github.com",
+	Crates:      "https://crates.io/api/v1/crates",
+}
+
+// EndpointsFor returns Endpoints that share a single base URL, e.g. a mirror or a test server.
+// Each registry is served under its own path prefix: /npm, /pypi, /goproxy, /github, /github.com and /crates.
+func EndpointsFor(baseURL string) Endpoints {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return Endpoints{
+		NpmRegistry: baseURL + "/npm",
+		PyPI:        baseURL + "/pypi",
+		GoProxy:     baseURL + "/goproxy",
+		GitHubAPI:   baseURL + "/github",
+		GitHub:      baseURL + "/github.com",
+		Crates:      baseURL + "/crates",
+	}
+}
+
+// latestVersioner is implemented by installers that can look up their latest version.
+// The returned version has the same form as Version().
+type latestVersioner interface {
+	LatestVersion(ctx context.Context, c *registryClient) (string, error)
+}
+
+type registryClient struct {
+	client    *http.Client
+	endpoints Endpoints
+	retry     retryPolicy
+	// logger logs the retries, or the standard logger if nil
+	logger *log.Logger
+}
+
+func (c *registryClient) get(ctx context.Context, u, accept string) ([]byte, error) {
+	var b []byte
+	err := c.retry.run(ctx, c.logger, func() error {
+		var err error
+		b, err = c.getOnce(ctx, u, accept)
+		return err
+	})
+	return b, err
+}
+
+func (c *registryClient) getOnce(ctx context.Context, u, accept string) ([]byte, error) {
+	ctx, w, stop := c.retry.watch(ctx)
+	defer stop()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	// crates.io requires a user agent
+	req.Header.Set("User-Agent", appName+"/"+lsmVersion())
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, w.transportError(ctx, u, err)
+	}
+	defer resp.Body.Close()
+	if err := c.retry.checkStatus(resp); err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(w.reader(resp.Body))
+	if err != nil {
+		return nil, w.transportError(ctx, u, err)
+	}
+	return b, nil
+}
+
+func (c *registryClient) getJSON(ctx context.Context, u string, v interface{}) error {
+	b, err := c.get(ctx, u, "application/json")
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func (c *registryClient) npmLatest(ctx context.Context, pkg string) (string, error) {
+	var v struct {
+		Version string `json:"version"`
+	}
+	u := fmt.Sprintf("%s/%s/latest", c.endpoints.NpmRegistry, strings.ReplaceAll(pkg, "/", "%2F"))
+	if err := c.getJSON(ctx, u, &v); err != nil {
+		return "", err
+	}
+	return v.Version, nil
+}
+
+func (c *registryClient) pypiLatest(ctx context.Context, pkg string) (string, error) {
+	var v struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	u := fmt.Sprintf("%s/%s/json", c.endpoints.PyPI, url.PathEscape(pkg))
+	if err := c.getJSON(ctx, u, &v); err != nil {
+		return "", err
+	}
+	return v.Info.Version, nil
+}
+
+func (c *registryClient) goLatest(ctx context.Context, module string) (string, error) {
+	var v struct {
+		Version string
+	}
+	u := fmt.Sprintf("%s/%s/@latest", c.endpoints.GoProxy, escapeModulePath(module))
+	if err := c.getJSON(ctx, u, &v); err != nil {
+		return "", err
+	}
+	return v.Version, nil
+}
+
+var goDirective = regexp.MustCompile(`(?m)^go\s+(\S+)\s*$`)
+
+// goModGoVersion returns the go directive in go.mod of the module version, or an empty string if none.
+func (c *registryClient) goModGoVersion(ctx context.Context, module, version string) (string, error) {
+	u := fmt.Sprintf("%s/%s/@v/%s.mod", c.endpoints.GoProxy, escapeModulePath(module), escapeModulePath(version))
+	b, err := c.get(ctx, u, "text/plain")
+	if err != nil {
+		return "", err
+	}
+	if m := goDirective.FindSubmatch(b); m != nil {
+		return string(m[1]), nil
+	}
+	return "", nil
+}
+
+func (c *registryClient) cratesLatest(ctx context.Context, crate string) (string, error) {
+	var v struct {
+		Crate struct {
+			MaxStableVersion string `json:"max_stable_version"`
+		} `json:"crate"`
+	}
+	u := fmt.Sprintf("%s/%s", c.endpoints.Crates, url.PathEscape(crate))
+	if err := c.getJSON(ctx, u, &v); err != nil {
+		return "", err
+	}
+	return v.Crate.MaxStableVersion, nil
+}
+
+func (c *registryClient) githubLatest(ctx context.Context, owner, repo string) (string, error) {
+	var v struct {
+		TagName string `json:"tag_name"`
+	}
+	u := fmt.Sprintf("%s/repos/%s/%s/releases/latest", c.endpoints.GitHubAPI, owner, repo)
+	if err := c.getJSON(ctx, u, &v); err != nil {
+		return "", err
+	}
+	return v.TagName, nil
+}
+
+// escapeModulePath escapes upper case letters as the module proxy protocol requires.
+func escapeModulePath(module string) string {
+	var b strings.Builder
+	for _, r := range module {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	errLatestUnknown = errors.New("latest version is unknown")
+
+	githubReleaseURL = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/releases/download/`)
+)
+
+// githubLatestForURL looks up the latest release for a GitHub release download URL template.
+func (c *registryClient) githubLatestForURL(ctx context.Context, tmpl string) (string, error) {
+	m := githubReleaseURL.FindStringSubmatch(tmpl)
+	if m == nil || !strings.Contains(tmpl, "{{.Version}}") {
+		return "", errLatestUnknown
+	}
+	v, err := c.githubLatest(ctx, m[1], m[2])
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(tmpl, "v{{.Version}}") {
+		v = strings.TrimPrefix(v, "v")
+	}
+	return v, nil
+}
+
+func (i *NpmInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.npmLatest(ctx, i.moduleName)
+}
+
+func (i *PipInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.pypiLatest(ctx, i.moduleName)
+}
+
+func (i *GoInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.goLatest(ctx, i.module)
+}
+
+func (i *CargoInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.cratesLatest(ctx, i.crate)
+}
+
+func (i *VSCodeExtensionInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.githubLatestForURL(ctx, i.vsixURL)
+}
+
+func (i *ArchiveInstaller) LatestVersion(ctx context.Context, c *registryClient) (string, error) {
+	return c.githubLatestForURL(ctx, i.url)
+}
@@ -0,0 +1,288 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile is the default file name of a lockfile.
+const LockFile = "lsm.lock"
+
+// Lock records the exact versions and artifacts resolved from a manifest.
+type Lock struct {
+	Servers []LockedServer `yaml:"servers"`
+}
+
+// LockedServer is a resolved language server.
+// Source is the package spec for installers without artifacts such as npm.
+// Such servers are pinned only by the version, and the integrity of the package is left to the package manager.
+type LockedServer struct {
+	Name      string           `yaml:"name"`
+	Version   string           `yaml:"version"`
+	Kind      string           `yaml:"kind"`
+	Source    string           `yaml:"source,omitempty"`
+	Artifacts []LockedArtifact `yaml:"artifacts,omitempty"`
+}
+
+// LockedArtifact is a download for a platform.
+// An artifact without OS and Arch is used on any platform.
+type LockedArtifact struct {
+	OS     string `yaml:"os,omitempty"`
+	Arch   string `yaml:"arch,omitempty"`
+	URL    string `yaml:"url"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// artifacter is implemented by installers that download a single artifact per platform.
+type artifacter interface {
+	artifactURL(s Support) (string, error)
+}
+
+// packager is implemented by installers that install a package from a package registry.
+type packager interface {
+	packageSpec() string
+}
+
+// LoadLock loads a lockfile.
+func LoadLock(path string) (*Lock, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l Lock
+	if err := yaml.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &l, nil
+}
+
+// WriteLock writes a lockfile.
+func WriteLock(path string, l *Lock) error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0666)
+}
+
+func (l *Lock) server(name string) (*LockedServer, bool) {
+	for n := range l.Servers {
+		if l.Servers[n].Name == name {
+			return &l.Servers[n], true
+		}
+	}
+	return nil, false
+}
+
+func (s *LockedServer) artifact(p Support) (*LockedArtifact, bool) {
+	for n, a := range s.Artifacts {
+		if (a.OS == p.os && a.Arch == p.arch) || (a.OS == "" && a.Arch == "") {
+			return &s.Artifacts[n], true
+		}
+	}
+	return nil, false
+}
+
+// pin pins the installer to the locked version and artifact digest.
+// It fails if the requested version or the artifact deviates from the lock.
+func (l *Lock) pin(i Installer, version string) error {
+	s, ok := l.server(i.Name())
+	if !ok {
+		return fmt.Errorf("%s is not locked in %s", i.Name(), LockFile)
+	}
+	if version != versionUnSpecified && !sameVersion(version, s.Version) {
+		return fmt.Errorf("%s@%s deviates from the locked version %s", i.Name(), version, s.Version)
+	}
+	i.SetVersion(s.Version)
+	art, ok := i.(artifacter)
+	if !ok {
+		return nil
+	}
+	b := i.base()
+	p := b.platform()
+	la, ok := s.artifact(p)
+	if !ok {
+		return fmt.Errorf("%s is not locked for %s/%s", i.Name(), p.os, p.arch)
+	}
+	u, err := art.artifactURL(p)
+	if err != nil {
+		return err
+	}
+	if u != la.URL {
+		return fmt.Errorf("%s: %s deviates from the locked artifact %s", i.Name(), u, la.URL)
+	}
+	b.checksums = checksums{digests: map[string]string{u: la.SHA256}}
+	b.requireChecksum = true
+	return nil
+}
+
+func urlFileName(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	return path.Base(parsed.Path), nil
+}
+
+// resolveVersion resolves the version to lock.
+// Unspecified versions are resolved to the installed one, the default one or the latest one in this order.
+func (a *App) resolveVersion(ctx context.Context, i Installer, want string) (string, error) {
+	if want != versionUnSpecified {
+		return want, nil
+	}
+	if v, ok := currentVersion(i); ok && v != versionUnSpecified {
+		return v, nil
+	}
+	if v := i.Version(); v != versionUnSpecified {
+		return v, nil
+	}
+	if lv, ok := i.(latestVersioner); ok {
+		return lv.LatestVersion(ctx, a.registryClient())
+	}
+	return "", fmt.Errorf("%s: %w", i.Name(), errLatestUnknown)
+}
+
+// artifactDigest returns the declared digest of the artifact, or downloads it to compute the digest.
+// The artifact is downloaded as the installation does, so that the retry policy and the download cache apply.
+func (a *App) artifactDigest(ctx context.Context, i Installer, p Support, u string) (string, error) {
+	b := i.base()
+	b.data = newTemplateData(i).withPlatform(p)
+	d, err := b.expectedChecksum(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	if d != "" {
+		return d, nil
+	}
+
+	tmp, err := ioutil.TempDir("", "lsm-lock-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	file, err := urlFileName(u)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	archive := filepath.Join(tmp, file)
+	if err := b.Download(req, archive); err != nil {
+		return "", err
+	}
+	digest, err := fileDigest(archive)
+	if err != nil {
+		return "", err
+	}
+	return normalizeDigest(digest), nil
+}
+
+func (a *App) lockServer(ctx context.Context, name, want string) (*LockedServer, error) {
+	i, err := a.getInstaller(name)
+	if err != nil {
+		return nil, err
+	}
+	version, err := a.resolveVersion(ctx, i, want)
+	if err != nil {
+		return nil, err
+	}
+	i.SetVersion(version)
+	s := &LockedServer{Name: name, Version: i.Version(), Kind: i.Kind()}
+	if p, ok := i.(packager); ok {
+		s.Source = p.packageSpec()
+	}
+	art, ok := i.(artifacter)
+	if !ok {
+		return s, nil
+	}
+	if err := a.prepare(i); err != nil {
+		return nil, err
+	}
+	platforms := i.Supports()
+	if len(platforms) == 0 {
+		host := i.base().platform()
+		agnostic, err := platformAgnostic(art, host)
+		if err != nil {
+			return nil, err
+		}
+		if agnostic {
+			platforms = []Support{{}} // any platform
+		} else {
+			// the supported platforms are unknown, so only the host artifact can be locked
+			log.Printf("%s: the artifact depends on the platform but no platforms are supported in the registry, locked only for %s/%s", name, host.os, host.arch)
+			platforms = []Support{host}
+		}
+	}
+	for _, p := range platforms {
+		target := p
+		if target == (Support{}) {
+			target = i.base().platform()
+		}
+		u, err := art.artifactURL(target)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := a.artifactDigest(ctx, i, target, u)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		s.Artifacts = append(s.Artifacts, LockedArtifact{OS: p.os, Arch: p.arch, URL: u, SHA256: digest})
+	}
+	return s, nil
+}
+
+// lockProbes are the platforms whose artifact URLs are compared to tell whether the artifact depends on the platform.
+var lockProbes = []Support{{os: linux, arch: amd64}, {os: linux, arch: arm64}, {os: darwin, arch: arm64}, {os: windows, arch: amd64}}
+
+// platformAgnostic reports whether the artifact URL is the same on every platform, so that one artifact can be locked for all of them.
+func platformAgnostic(art artifacter, host Support) (bool, error) {
+	want, err := art.artifactURL(host)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range lockProbes {
+		u, err := art.artifactURL(p)
+		if err != nil {
+			return false, err
+		}
+		if u != want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Lock resolves the exact versions and artifacts of the language servers in the manifest.
+func (a *App) Lock(ctx context.Context, m *Manifest) (*Lock, error) {
+	names := make([]string, 0, len(m.Servers))
+	for name := range m.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	l := &Lock{Servers: make([]LockedServer, 0, len(names))}
+	for _, name := range names {
+		s, err := a.lockServer(ctx, name, m.Servers[name])
+		if err != nil {
+			return nil, err
+		}
+		l.Servers = append(l.Servers, *s)
+	}
+	return l, nil
+}
+
+// SetFrozen makes Install refuse anything that deviates from the lock.
+func (a *App) SetFrozen(l *Lock) {
+	a.lock = l
+}
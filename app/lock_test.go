@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLockTestApp(t *testing.T, content string) (*App, *httptest.Server) {
+	t.Helper()
+	return newLockTestAppWithURL(t, content, "/{{.Version}}/foo-ls")
+}
+
+func newLockTestAppWithURL(t *testing.T, content, path string) (*App, *httptest.Server) {
+	t.Helper()
+	return newLockTestAppWithHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}), path)
+}
+
+func newLockTestAppWithHandler(t *testing.T, h http.Handler, path string) (*App, *httptest.Server) {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+
+	a, _ := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 1.0.0
+    url: `+ts.URL+path+`
+    bin: foo-ls
+`)
+	return a, ts
+}
+
+func TestApp_Lock(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	a, ts := newLockTestApp(t, content)
+
+	l, err := a.Lock(context.Background(), &Manifest{Servers: map[string]string{"foo-ls": "1.1.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, []LockedServer{{
+		Name:    "foo-ls",
+		Version: "1.1.0",
+		Kind:    kindArchive,
+		Artifacts: []LockedArtifact{{
+			URL:    ts.URL + "/1.1.0/foo-ls",
+			SHA256: hex.EncodeToString(sum[:]),
+		}},
+	}}, l.Servers)
+
+	// round trip
+	p := filepath.Join(t.TempDir(), LockFile)
+	if err := WriteLock(p, l); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadLock(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, l, loaded)
+}
+
+func TestApp_Lock_retry(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	var n int
+	a, _ := newLockTestAppWithHandler(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}), "/{{.Version}}/foo-ls")
+	a.retry = retryPolicy{retries: 2, timeout: time.Second, backoff: time.Millisecond, maxWait: time.Second}
+
+	l, err := a.Lock(context.Background(), &Manifest{Servers: map[string]string{"foo-ls": "1.1.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, hex.EncodeToString(sum[:]), l.Servers[0].Artifacts[0].SHA256)
+	assert.Equal(t, 2, n)
+}
+
+func TestApp_Lock_platformDependent(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	a, ts := newLockTestAppWithURL(t, content, "/{{.Version}}/foo-ls_{{.OS}}_{{.Arch}}")
+
+	l, err := a.Lock(context.Background(), &Manifest{Servers: map[string]string{"foo-ls": "1.1.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, []LockedArtifact{{
+		OS:     runtime.GOOS,
+		Arch:   runtime.GOARCH,
+		URL:    ts.URL + "/1.1.0/foo-ls_" + runtime.GOOS + "_" + runtime.GOARCH,
+		SHA256: hex.EncodeToString(sum[:]),
+	}}, l.Servers[0].Artifacts)
+}
+
+func TestApp_Install_frozen(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	sum := sha256.Sum256([]byte(content))
+	digest := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		spec     string
+		digest   string
+		insecure bool
+		wantErr  bool
+	}{
+		{name: "locked", spec: "foo-ls", digest: digest},
+		{name: "same version", spec: "foo-ls@1.1.0", digest: digest},
+		{name: "other version", spec: "foo-ls@1.2.0", digest: digest, wantErr: true},
+		{name: "digest mismatch", spec: "foo-ls", digest: "0000", wantErr: true},
+		{name: "insecure", spec: "foo-ls", digest: digest, insecure: true, wantErr: true},
+		{name: "not locked", spec: "gopls", digest: digest, wantErr: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a, ts := newLockTestApp(t, content)
+			a.SetFrozen(&Lock{Servers: []LockedServer{{
+				Name:      "foo-ls",
+				Version:   "1.1.0",
+				Kind:      kindArchive,
+				Artifacts: []LockedArtifact{{URL: ts.URL + "/1.1.0/foo-ls", SHA256: tt.digest}},
+			}}})
+			a.SetInsecureSkipVerify(tt.insecure)
+			err := a.Install(context.Background(), tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			i, _ := a.getInstaller("foo-ls")
+			r, err := readReceipt(i.Dir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "1.1.0", r.Version)
+		})
+	}
+}
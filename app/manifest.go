@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the default file name of a project manifest.
+const ManifestFile = "lsm.yaml"
+
+// Manifest lists the language servers and versions a project needs.
+// An empty version means any installed version, or the default one.
+type Manifest struct {
+	Servers map[string]string `yaml:"servers"`
+}
+
+// LoadManifest loads a project manifest.
+func LoadManifest(path string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+const (
+	syncInstall   = "install"
+	syncUpgrade   = "upgrade"
+	syncDowngrade = "downgrade"
+	syncRemove    = "remove"
+	syncKeep      = "keep"
+)
+
+type syncAction struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// SyncOptions are options for App.Sync.
+type SyncOptions struct {
+	// DryRun only shows the plan.
+	DryRun bool
+	// Prune removes installed language servers that are not listed in the manifest.
+	Prune bool
+	Style ListStyle
+}
+
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+func currentVersion(i Installer) (string, bool) {
+	if !isInstalled(i) {
+		return "", false
+	}
+	if r, err := readReceipt(i.Dir()); err == nil {
+		return r.Version, true
+	}
+	return versionUnSpecified, true
+}
+
+func (a *App) syncPlan(m *Manifest, prune bool) ([]syncAction, error) {
+	names := make([]string, 0, len(m.Servers))
+	for name := range m.Servers {
+		if _, err := a.getInstaller(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plan := make([]syncAction, 0, len(names))
+	for _, name := range names {
+		i, _ := a.getInstaller(name)
+		want := m.Servers[name]
+		installed, ok := currentVersion(i)
+		switch {
+		case !ok:
+			to := want
+			if to == versionUnSpecified {
+				to = i.Version()
+			}
+			plan = append(plan, syncAction{Name: name, Action: syncInstall, To: to})
+		case want == versionUnSpecified || sameVersion(installed, want):
+			plan = append(plan, syncAction{Name: name, Action: syncKeep, From: installed, To: installed})
+		case isNewer(installed, want):
+			plan = append(plan, syncAction{Name: name, Action: syncUpgrade, From: installed, To: want})
+		default:
+			plan = append(plan, syncAction{Name: name, Action: syncDowngrade, From: installed, To: want})
+		}
+	}
+
+	if prune {
+		var removes []syncAction
+		for name, i := range a.installers {
+			if _, ok := m.Servers[name]; ok {
+				continue
+			}
+			if installed, ok := currentVersion(i); ok {
+				removes = append(removes, syncAction{Name: name, Action: syncRemove, From: installed})
+			}
+		}
+		sort.Slice(removes, func(x, y int) bool {
+			return removes[x].Name < removes[y].Name
+		})
+		plan = append(plan, removes...)
+	}
+	return plan, nil
+}
+
+// Sync makes the installed language servers match the manifest.
+// The plan is shown before acting.
+func (a *App) Sync(ctx context.Context, m *Manifest, opts SyncOptions) error {
+	plan, err := a.syncPlan(m, opts.Prune)
+	if err != nil {
+		return err
+	}
+	if err := a.render(plan, opts.Style); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return nil
+	}
+	for _, s := range plan {
+		switch s.Action {
+		case syncInstall:
+			spec := s.Name
+			if want := m.Servers[s.Name]; want != versionUnSpecified {
+				spec += "@" + want
+			}
+			if err := a.Install(ctx, spec); err != nil {
+				return err
+			}
+		case syncUpgrade, syncDowngrade:
+			if err := a.Install(ctx, s.Name+"@"+s.To); err != nil {
+				return err
+			}
+		case syncRemove:
+			if err := a.Uninstall(ctx, s.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadManifest(t *testing.T) {
+	p := filepath.Join(t.TempDir(), ManifestFile)
+	const b = `
+servers:
+  gopls: v0.9.1
+  rust-analyzer:
+`
+	if err := ioutil.WriteFile(p, []byte(b), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"gopls": "v0.9.1", "rust-analyzer": ""}, m.Servers)
+}
+
+func TestApp_Sync(t *testing.T) {
+	newApp := func(t *testing.T) *App {
+		t.Helper()
+		a, err := New(Options{BaseDir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		a.installers = make(map[string]Installer)
+		for name, version := range map[string]string{
+			"upgrade-ls":   "1.0.0",
+			"downgrade-ls": "2.0.0",
+			"keep-ls":      "1.0.0",
+			"pinned-ls":    "v1.0.0",
+			"unlisted-ls":  "1.0.0",
+			"install-ls":   "",
+		} {
+			i := newFakeInstaller(a.baseDir, name)
+			a.installers[name] = i
+			if version != "" {
+				fakeInstall(t, i, version)
+			}
+		}
+		return a
+	}
+	m := &Manifest{Servers: map[string]string{
+		"upgrade-ls":   "1.1.0",
+		"downgrade-ls": "1.0.0",
+		"keep-ls":      "",
+		"pinned-ls":    "1.0.0",
+		"install-ls":   "0.1.0",
+	}}
+	want := []syncAction{
+		{Name: "downgrade-ls", Action: syncDowngrade, From: "2.0.0", To: "1.0.0"},
+		{Name: "install-ls", Action: syncInstall, To: "0.1.0"},
+		{Name: "keep-ls", Action: syncKeep, From: "1.0.0", To: "1.0.0"},
+		{Name: "pinned-ls", Action: syncKeep, From: "v1.0.0", To: "v1.0.0"},
+		{Name: "upgrade-ls", Action: syncUpgrade, From: "1.0.0", To: "1.1.0"},
+		{Name: "unlisted-ls", Action: syncRemove, From: "1.0.0"},
+	}
+
+	t.Run("dry-run", func(t *testing.T) {
+		a := newApp(t)
+		var buf bytes.Buffer
+		a.out = &buf
+		if err := a.Sync(context.Background(), m, SyncOptions{DryRun: true, Prune: true, Style: ListStyleJSON}); err != nil {
+			t.Fatal(err)
+		}
+		var got []syncAction
+		if err := json.NewDecoder(&buf).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, want, got)
+		i, _ := a.getInstaller("install-ls")
+		assert.False(t, isInstalled(i))
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		a := newApp(t)
+		a.out = ioutil.Discard
+		if err := a.Sync(context.Background(), m, SyncOptions{Prune: true}); err != nil {
+			t.Fatal(err)
+		}
+		for name, version := range map[string]string{
+			"upgrade-ls":   "1.1.0",
+			"downgrade-ls": "1.0.0",
+			"keep-ls":      "1.0.0",
+			"install-ls":   "0.1.0",
+		} {
+			i, _ := a.getInstaller(name)
+			got, ok := currentVersion(i)
+			assert.True(t, ok, name)
+			assert.Equal(t, version, got, name)
+		}
+		i, _ := a.getInstaller("unlisted-ls")
+		assert.False(t, isInstalled(i))
+	})
+
+	t.Run("unknown server", func(t *testing.T) {
+		a := newApp(t)
+		err := a.Sync(context.Background(), &Manifest{Servers: map[string]string{"unknown-ls": ""}}, SyncOptions{DryRun: true})
+		assert.Error(t, err)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"path/filepath"
+	"strings"
 )
 
 type MetalsInstaller struct {
@@ -15,6 +16,8 @@ var _ Installer = (*MetalsInstaller)(nil)
 func NewMetalsInstaller(baseDir string) *MetalsInstaller {
 	var i MetalsInstaller
 	i.baseInstaller = newBaseInstaller(filepath.Join(baseDir, i.Name()))
+	// the coursier launcher is always the latest one
+	i.noCache = true
 	return &i
 }
 
@@ -33,16 +36,20 @@ func (i *MetalsInstaller) Requires() []string {
 	return []string{"java"}
 }
 
+func (i *MetalsInstaller) Kind() string {
+	return kindCoursier
+}
+
 func (i *MetalsInstaller) Version() string {
-	return "0.9.0"
+	return strings.TrimPrefix(i.versionOr("0.9.0"), "v")
 }
 
-func (i *MetalsInstaller) Install(ctx context.Context) error {
+func (i *MetalsInstaller) Install(ctx context.Context, dir string) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://git.io/coursier-cli", nil)
 	if err != nil {
 		return err
 	}
-	coursier := filepath.Join(i.Dir(), "coursier")
+	coursier := filepath.Join(dir, "coursier")
 	if err := i.Download(req, coursier); err != nil {
 		return err
 	}
@@ -51,14 +58,19 @@ func (i *MetalsInstaller) Install(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		coursierBat := filepath.Join(i.Dir(), "coursier.bat")
+		coursierBat := filepath.Join(dir, "coursier.bat")
 		if err := i.Download(req, coursierBat); err != nil {
 			return err
 		}
 	}
-	return i.CmdRun(ctx,
-		"java", "-jar", "coursier", "bootstrap",
-		"--ttl", "Inf", "org.scalameta:metals_2.12:"+i.Version(), "-r", "bintray:scalacenter/releases", "-r", "sonatype:public",
-		"-o", filepath.Join(i.Dir(), i.Name()),
-	)
+	artifact := "org.scalameta:metals_2.12:" + i.Version()
+	if err := i.CmdRun(ctx, dir, "java", i.withInstallArgs([]string{
+		"-jar", "coursier", "bootstrap",
+		"--ttl", "Inf", artifact, "-r", "bintray:scalacenter/releases", "-r", "sonatype:public",
+		"-o", filepath.Join(dir, i.Name()),
+	})...); err != nil {
+		return err
+	}
+	i.record("maven:"+artifact, "")
+	return nil
 }
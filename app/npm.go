@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 )
@@ -10,38 +12,47 @@ import (
 type NpmInstaller struct {
 	baseInstaller
 
+	name       string
 	moduleName string
 	binName    string
 }
 
 var _ Installer = (*NpmInstaller)(nil)
 
-func NewNpmInstaller(baseDir, moduleName, binName string) *NpmInstaller {
+func NewNpmInstaller(baseDir, name, moduleName, binName string) *NpmInstaller {
 	return &NpmInstaller{
-		baseInstaller: newBaseInstaller(filepath.Join(baseDir, moduleName)),
+		baseInstaller: newBaseInstaller(filepath.Join(baseDir, name)),
+		name:          name,
 		moduleName:    moduleName,
 		binName:       binName,
 	}
 }
 
 func (i *NpmInstaller) Name() string {
-	return i.moduleName
+	return i.name
 }
 
 func (i *NpmInstaller) BinName() string {
 	return i.binName
 }
 
-func (i *NpmInstaller) Version() string {
-	return versionUnSpecified
-}
-
 func (i *NpmInstaller) Requires() []string {
 	return []string{"node", "npm"}
 }
 
-func (i *NpmInstaller) Install(ctx context.Context) error {
-	f, err := os.Create(filepath.Join(i.Dir(), "package.json"))
+func (i *NpmInstaller) Kind() string {
+	return kindNpm
+}
+
+func (i *NpmInstaller) packageSpec() string {
+	if v := i.Version(); v != versionUnSpecified {
+		return i.moduleName + "@" + v
+	}
+	return i.moduleName
+}
+
+func (i *NpmInstaller) Install(ctx context.Context, dir string) error {
+	f, err := os.Create(filepath.Join(dir, "package.json"))
 	if err != nil {
 		return err
 	}
@@ -51,14 +62,35 @@ func (i *NpmInstaller) Install(ctx context.Context) error {
 		return err
 	}
 
-	if err := i.CmdRun(ctx, "npm", "install", i.Name()); err != nil {
+	pkg := i.packageSpec()
+	if err := i.CmdRun(ctx, dir, "npm", i.withInstallArgs([]string{"install"}, pkg)...); err != nil {
 		return err
 	}
+	i.record("npm:"+pkg, "")
+	if v, err := i.installedVersion(dir); err == nil {
+		i.resolvedVersion = v
+	} else {
+		i.logger().Println(err)
+	}
 
 	src := filepath.Join("node_modules", ".bin", i.BinName())
-	dst := filepath.Join(i.Dir(), i.BinName())
+	dst := filepath.Join(dir, i.BinName())
 	if err := os.Symlink(src, dst); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (i *NpmInstaller) installedVersion(dir string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "node_modules", i.moduleName, "package.json"))
+	if err != nil {
+		return "", err
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(b, &pkg); err != nil {
+		return "", err
+	}
+	return pkg.Version, nil
+}
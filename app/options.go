@@ -0,0 +1,175 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultJobs = 4
+
+// Options configure App, typically loaded from the config file.
+// The zero value is the default configuration.
+type Options struct {
+	// BaseDir is the directory to install language servers into, where a leading "~" is the home directory.
+	BaseDir string `mapstructure:"base_dir"`
+	// CacheDir is the directory to keep the download cache in as "lsm", which defaults to the cache directory in the data directory of lsm.
+	CacheDir string `mapstructure:"cache_dir"`
+	// Output is the default output style of lists.
+	Output ListStyle `mapstructure:"output"`
+	// Proxy is the URL of the HTTP proxy used for downloads and install commands instead of HTTPS_PROXY and HTTP_PROXY.
+	Proxy string `mapstructure:"proxy"`
+	// Jobs is the default number of language servers installed in parallel.
+	Jobs int `mapstructure:"jobs"`
+	// Retries is the number of retries of failed requests, which defaults to 3. A negative number disables retries.
+	Retries int `mapstructure:"retries"`
+	// Timeout aborts a request that receives no data for the duration, which defaults to 30s. A negative duration disables it.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Registries are additional registry files.
+	Registries []string `mapstructure:"registries"`
+	// Servers override the language servers with the same names.
+	Servers map[string]ServerOptions `mapstructure:"servers"`
+}
+
+// ServerOptions override a language server.
+type ServerOptions struct {
+	// Version is the version installed unless another one is specified.
+	Version string `mapstructure:"version"`
+	// Env is added to the environment of the install commands as KEY=VALUE.
+	Env []string `mapstructure:"env"`
+	// URL replaces the download URL template, e.g. with a mirror.
+	URL string `mapstructure:"url"`
+	// InstallArgs replace the arguments added to the install command of the package manager.
+	InstallArgs []string `mapstructure:"install_args"`
+	// Args replace the arguments passed to the language server before the ones given at launch.
+	Args []string `mapstructure:"args"`
+	// RuntimeEnv is added to the environment of the language server as KEY=VALUE.
+	RuntimeEnv []string `mapstructure:"runtime_env"`
+}
+
+// modCacheSetter is implemented by installers that share a module cache.
+type modCacheSetter interface {
+	setModCache(dir string)
+}
+
+// urlSetter is implemented by installers that download a URL template.
+type urlSetter interface {
+	setURL(u string)
+}
+
+// expandHome replaces a leading "~" of the path with the home directory.
+func expandHome(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(p, `~\`) {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, p[1:]), nil
+}
+
+// proxyEnv returns the environment variables that make install commands use the proxy.
+func proxyEnv(proxy string) []string {
+	if proxy == "" {
+		return nil
+	}
+	var env []string
+	for _, k := range []string{"HTTPS_PROXY", "HTTP_PROXY", "https_proxy", "http_proxy"} {
+		env = append(env, k+"="+proxy)
+	}
+	return env
+}
+
+func newHTTPClient(proxy string) (*http.Client, error) {
+	if proxy == "" {
+		return http.DefaultClient, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyURL(u)
+	return &http.Client{Transport: t}, nil
+}
+
+// proxyURL returns the proxy used for the request, if any.
+func (a *App) proxyURL(req *http.Request) (*url.URL, error) {
+	if t, ok := a.client.Transport.(*http.Transport); ok && t.Proxy != nil {
+		return t.Proxy(req)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// applyOptions applies the per-server overrides.
+func (a *App) applyOptions(servers map[string]ServerOptions) error {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o := servers[name]
+		i, err := a.getInstaller(name)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		e := RegistryEntry{
+			Name:        name,
+			Version:     o.Version,
+			Env:         o.Env,
+			InstallArgs: o.InstallArgs,
+			Args:        o.Args,
+			RuntimeEnv:  o.RuntimeEnv,
+		}
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		if err := e.apply(i); err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		if o.URL != "" {
+			s, ok := i.(urlSetter)
+			if !ok {
+				return fmt.Errorf("config: %s: url cannot be overridden for %s", name, i.Kind())
+			}
+			s.setURL(o.URL)
+		}
+	}
+	return nil
+}
+
+// prepare passes the settings of the app to the installer.
+func (a *App) prepare(i Installer) error {
+	if err := a.setTarget(i); err != nil {
+		return err
+	}
+	b := i.base()
+	b.insecureSkipVerify = a.insecureSkipVerify
+	b.cache = a.cache
+	b.registry = a.registryClient()
+	b.retry = a.retry
+	b.proxyEnv = proxyEnv(a.proxy)
+	if s, ok := i.(modCacheSetter); ok {
+		s.setModCache(a.modCache)
+	}
+	return nil
+}
+
+func (a *App) registryClient() *registryClient {
+	return &registryClient{client: a.client, endpoints: a.endpoints, retry: a.retry}
+}
+
+// listStyle returns the style, or the default style if undefined.
+func (a *App) listStyle(style ListStyle) ListStyle {
+	if style == ListStyleUndefined {
+		return a.output
+	}
+	return style
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_options(t *testing.T) {
+	if isWindows {
+		t.Skip()
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	registry := filepath.Join(home, "registry.yaml")
+	if err := ioutil.WriteFile(registry, []byte(`
+servers:
+  - name: foo-ls
+    kind: archive
+    url: https://example.com/foo-ls/{{.Version}}/foo-ls
+    bin: foo-ls
+`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(Options{
+		BaseDir:    "~/lsm/servers",
+		Output:     ListStyleJSON,
+		Jobs:       2,
+		Retries:    -1,
+		Timeout:    time.Minute,
+		Registries: []string{"~/registry.yaml"},
+		Servers: map[string]ServerOptions{
+			"gopls":  {Version: "v0.9.1", Env: []string{"GOFLAGS=-mod=mod"}, InstallArgs: []string{"-trimpath"}, Args: []string{"-rpc.trace"}},
+			"foo-ls": {Version: "1.0.0", URL: "https://mirror.example.com/foo-ls/{{.Version}}/foo-ls"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, filepath.Join(home, "lsm", "servers"), a.baseDir)
+	assert.Equal(t, ListStyleJSON, a.listStyle(ListStyleUndefined))
+	assert.Equal(t, ListStyleTable, a.listStyle(ListStyleTable))
+	assert.Equal(t, 2, a.jobs)
+	assert.Equal(t, 0, a.retry.retries)
+	assert.Equal(t, time.Minute, a.retry.timeout)
+
+	gopls, err := a.getInstaller("gopls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "v0.9.1", gopls.Version())
+	gopls.SetVersion("v0.10.0")
+	assert.Equal(t, "v0.10.0", gopls.Version())
+	assert.Equal(t, "-mod=mod", getenv(gopls.base().environ(), "GOFLAGS"))
+	assert.Equal(t, []string{"install", "-trimpath", "gopls"}, gopls.base().withInstallArgs([]string{"install"}, "gopls"))
+	assert.Equal(t, []string{"-rpc.trace", "serve"}, launchArgs(gopls, []string{"serve"}))
+
+	foo, err := a.getInstaller("foo-ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := foo.(artifacter).artifactURL(foo.base().platform())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "https://mirror.example.com/foo-ls/1.0.0/foo-ls", u)
+
+	var buf bytes.Buffer
+	a.out = &buf
+	if err := a.List(context.Background(), ListStyleUndefined); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "[", buf.String()[:1])
+}
+
+func TestNew_options_invalid(t *testing.T) {
+	tests := map[string]Options{
+		"unknown server":          {Servers: map[string]ServerOptions{"unknown-ls": {Version: "1.0.0"}}},
+		"invalid env":             {Servers: map[string]ServerOptions{"gopls": {Env: []string{"GOFLAGS"}}}},
+		"url of package":          {Servers: map[string]ServerOptions{"gopls": {URL: "https://example.com"}}},
+		"invalid runtime env":     {Servers: map[string]ServerOptions{"gopls": {RuntimeEnv: []string{"=1"}}}},
+		"install args of archive": {Servers: map[string]ServerOptions{"rust-analyzer": {InstallArgs: []string{"-v"}}}},
+		"invalid proxy":           {Proxy: "http://proxy:port"},
+		"no registry":             {Registries: []string{"no-such-registry.yaml"}},
+	}
+	for name, opts := range tests {
+		opts.BaseDir = t.TempDir()
+		_, err := New(opts)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNew_options_proxy(t *testing.T) {
+	// the proxy serves the requested URL itself
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "example.invalid", r.URL.Host)
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer proxy.Close()
+
+	a, i := newRegistryTestAppWithOptions(t, Options{Proxy: proxy.URL}, `
+servers:
+  - name: foo-ls
+    kind: archive
+    url: http://example.invalid/{{.Version}}/foo-ls
+    bin: foo-ls
+`)
+	if err := a.Install(context.Background(), "foo-ls@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isInstalled(i))
+	assert.Contains(t, i.base().environ(), "HTTPS_PROXY="+proxy.URL)
+}
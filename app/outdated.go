@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+type outdatedServer struct {
+	Name      string `json:"name"`
+	Installed string `json:"installed"`
+	Latest    string `json:"latest"`
+	Outdated  bool   `json:"outdated"`
+}
+
+type updatedServer struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// isNewer reports whether latest is newer than installed.
+// It reports false if either version is not semver, since which one is newer is unknown and updating might downgrade.
+func isNewer(installed, latest string) bool {
+	if latest == versionUnSpecified {
+		return false
+	}
+	if installed == versionUnSpecified {
+		return true
+	}
+	iv, err1 := semver.NewVersion(installed)
+	lv, err2 := semver.NewVersion(latest)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return lv.GreaterThan(iv)
+}
+
+// outdated compares the installed language servers with the latest versions.
+// If names is empty, all installed language servers are checked.
+func (a *App) outdated(ctx context.Context, names []string) ([]outdatedServer, error) {
+	installers := make([]Installer, 0, len(a.installers))
+	if len(names) == 0 {
+		for _, i := range a.installers {
+			if isInstalled(i) {
+				installers = append(installers, i)
+			}
+		}
+	} else {
+		for _, name := range names {
+			i, err := a.getInstaller(name)
+			if err != nil {
+				return nil, err
+			}
+			if !isInstalled(i) {
+				return nil, errors.New(name + " is not installed")
+			}
+			installers = append(installers, i)
+		}
+	}
+	sort.Slice(installers, func(x, y int) bool {
+		return installers[x].Name() < installers[y].Name()
+	})
+
+	c := a.registryClient()
+	list := make([]outdatedServer, 0, len(installers))
+	for _, i := range installers {
+		s := outdatedServer{Name: i.Name()}
+		if r, err := readReceipt(i.Dir()); err == nil {
+			s.Installed = r.Version
+		}
+		if lv, ok := i.(latestVersioner); ok {
+			latest, err := lv.LatestVersion(ctx, c)
+			if err != nil {
+				log.Printf("%s: %v", i.Name(), err)
+			} else {
+				s.Latest = latest
+			}
+		}
+		s.Outdated = isNewer(s.Installed, s.Latest)
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// Outdated shows the installed language servers with their latest versions.
+func (a *App) Outdated(ctx context.Context, names []string, style ListStyle) error {
+	list, err := a.outdated(ctx, names)
+	if err != nil {
+		return err
+	}
+	return a.render(list, style)
+}
+
+// Update reinstalls the outdated language servers with their latest versions.
+func (a *App) Update(ctx context.Context, names []string, style ListStyle) error {
+	list, err := a.outdated(ctx, names)
+	if err != nil {
+		return err
+	}
+	updated := make([]updatedServer, 0, len(list))
+	for _, s := range list {
+		if !s.Outdated {
+			continue
+		}
+		if err := a.Install(ctx, s.Name+"@"+s.Latest); err != nil {
+			return err
+		}
+		updated = append(updated, updatedServer{Name: s.Name, From: s.Installed, To: s.Latest})
+	}
+	return a.render(updated, style)
+}
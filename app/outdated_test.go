@@ -0,0 +1,149 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRegistryTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/npm/typescript-language-server/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "typescript-language-server", "version": "1.0.0"}`)
+	})
+	mux.HandleFunc("/npm/fake-ls/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "fake-ls", "version": "2.0.0"}`)
+	})
+	mux.HandleFunc("/pypi/python-language-server/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"info": {"version": "0.36.2"}}`)
+	})
+	mux.HandleFunc("/goproxy/golang.org/x/tools/gopls/@latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Version": "v0.9.1"}`)
+	})
+	mux.HandleFunc("/github/repos/mattn/efm-langserver/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"tag_name": "v0.0.44"}`)
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// fakeInstall makes the language server look like installed with the version.
+func fakeInstall(t *testing.T, i Installer, version string) {
+	t.Helper()
+	if err := os.MkdirAll(i.Dir(), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(i.Dir(), i.BinName()), []byte("#!/bin/sh\n"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeReceipt(i.Dir(), &Receipt{Name: i.Name(), Version: version, Kind: i.Kind()}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApp_Outdated(t *testing.T) {
+	ts := newRegistryTestServer(t)
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetEndpoints(EndpointsFor(ts.URL))
+	for name, version := range map[string]string{
+		"typescript-language-server": "1.0.0",
+		"python-language-server":     "0.31.0",
+		"gopls":                      "v0.4.0",
+		"efm-langserver":             "0.0.14",
+	} {
+		i, err := a.getInstaller(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fakeInstall(t, i, version)
+	}
+
+	var buf bytes.Buffer
+	a.out = &buf
+	if err := a.Outdated(context.Background(), nil, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got []outdatedServer
+	if err := json.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := []outdatedServer{
+		{Name: "efm-langserver", Installed: "0.0.14", Latest: "0.0.44", Outdated: true},
+		{Name: "gopls", Installed: "v0.4.0", Latest: "v0.9.1", Outdated: true},
+		{Name: "python-language-server", Installed: "0.31.0", Latest: "0.36.2", Outdated: true},
+		{Name: "typescript-language-server", Installed: "1.0.0", Latest: "1.0.0", Outdated: false},
+	}
+	assert.Equal(t, want, got)
+
+	buf.Reset()
+	if err := a.Outdated(context.Background(), nil, ListStyleTable); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, buf.String(), "0.0.44")
+
+	err = a.Outdated(context.Background(), []string{"rust-analyzer"}, ListStyleJSON)
+	assert.Error(t, err, "not installed")
+}
+
+func TestApp_Update(t *testing.T) {
+	ts := newRegistryTestServer(t)
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.SetEndpoints(EndpointsFor(ts.URL))
+	i := newFakeInstaller(a.baseDir, "fake-ls")
+	a.installers[i.Name()] = i
+	fakeInstall(t, i, "1.0.0")
+
+	var buf bytes.Buffer
+	a.out = &buf
+	if err := a.Update(context.Background(), nil, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var got []updatedServer
+	if err := json.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []updatedServer{{Name: "fake-ls", From: "1.0.0", To: "2.0.0"}}, got)
+
+	r, err := readReceipt(i.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2.0.0", r.Version)
+}
+
+func Test_isNewer(t *testing.T) {
+	tests := []struct {
+		installed, latest string
+		want              bool
+	}{
+		{"0.0.14", "0.0.44", true},
+		{"v0.9.1", "v0.9.1", false},
+		{"v0.10.0", "v0.9.1", false},
+		{"2020-05-11", "2022-08-08", true},
+		{"", "1.0.0", true},
+		{"1.0.0", "", false},
+		{"release-a", "release-b", false},
+		{"release-b", "release-a", false},
+		{"1.0.0", "nightly", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isNewer(tt.installed, tt.latest), tt)
+	}
+}
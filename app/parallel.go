@@ -0,0 +1,103 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	installOK     = "ok"
+	installFailed = "failed"
+)
+
+type installResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+	Error   string `json:"error"`
+}
+
+// InstallAll installs the language servers specified as "name" or "name@version",
+// running at most jobs installations at a time.
+// When more than one runs at a time, the output of each installation is buffered
+// and written at once, prefixed by its name, when it finishes.
+// A failure does not stop the others; the results are shown as a summary.
+func (a *App) InstallAll(ctx context.Context, specs []string, jobs int, style ListStyle) error {
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		name, _ := splitSpec(spec)
+		if _, err := a.getInstaller(name); err != nil {
+			return err
+		}
+		if seen[name] {
+			return fmt.Errorf("%s is specified more than once", name)
+		}
+		seen[name] = true
+	}
+	if jobs < 1 {
+		jobs = a.jobs
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		sem     = make(chan struct{}, jobs)
+		results = make([]installResult, len(specs))
+	)
+	for n, spec := range specs {
+		n, spec := n, spec
+		name, version := splitSpec(spec)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var buf bytes.Buffer
+			if jobs > 1 {
+				i, _ := a.getInstaller(name)
+				i.base().setOutput(&buf)
+			}
+			res := installResult{Name: name, Version: version, Status: installOK}
+			r, err := a.install(ctx, spec)
+			if err != nil {
+				res.Status = installFailed
+				res.Error = err.Error()
+			} else {
+				res.Version = r.Version
+			}
+			results[n] = res
+
+			mu.Lock()
+			defer mu.Unlock()
+			writePrefixed(a.errOut, name, &buf)
+		}()
+	}
+	wg.Wait()
+
+	if err := a.render(results, style); err != nil {
+		return err
+	}
+	var failed int
+	for _, r := range results {
+		if r.Status == installFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d installations failed", failed, len(results))
+	}
+	return nil
+}
+
+// writePrefixed writes each line read from r to w with the prefix "[name] ".
+func writePrefixed(w io.Writer, name string, r io.Reader) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fmt.Fprintf(w, "[%s] %s\n", name, s.Text())
+	}
+}
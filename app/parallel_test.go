@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_InstallAll_summary(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a-ls", "b-ls", "c-ls"} {
+		a.installers[name] = newFakeInstaller(a.baseDir, name)
+	}
+	a.installers["b-ls"].(*fakeInstaller).err = errors.New("network is down")
+	var out, errOut bytes.Buffer
+	a.out = &out
+	a.errOut = &errOut
+
+	err = a.InstallAll(context.Background(), []string{"a-ls@1.0.0", "b-ls", "c-ls@2.0.0"}, 2, ListStyleJSON)
+	assert.EqualError(t, err, "1 of 3 installations failed")
+
+	var results []installResult
+	if err := json.NewDecoder(&out).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []installResult{
+		{Name: "a-ls", Version: "1.0.0", Status: installOK},
+		{Name: "b-ls", Status: installFailed, Error: "network is down"},
+		{Name: "c-ls", Version: "2.0.0", Status: installOK},
+	}, results)
+	assert.True(t, isInstalled(a.installers["a-ls"]))
+	assert.False(t, isInstalled(a.installers["b-ls"]))
+	assert.True(t, isInstalled(a.installers["c-ls"]))
+
+	for _, name := range []string{"a-ls", "b-ls", "c-ls"} {
+		assert.Contains(t, errOut.String(), "["+name+"] installing "+name+"\n")
+	}
+	// logged with the output of each installation
+	assert.Regexp(t, `\[a-ls\] .* a-ls 1\.0\.0 installed into `, errOut.String())
+	assert.Regexp(t, `\[c-ls\] .* c-ls 2\.0\.0 installed into `, errOut.String())
+}
+
+func TestApp_InstallAll_duplicated(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.installers["a-ls"] = newFakeInstaller(a.baseDir, "a-ls")
+	assert.Error(t, a.InstallAll(context.Background(), []string{"a-ls@1.0.0", "a-ls@2.0.0"}, 2, ListStyleJSON))
+	assert.False(t, isInstalled(a.installers["a-ls"]))
+}
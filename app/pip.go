@@ -1,8 +1,10 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,7 +21,7 @@ const (
 type PipInstaller struct {
 	baseInstaller
 
-	python, moduleName, binName string
+	python, name, moduleName, binName string
 }
 
 var _ Installer = (*PipInstaller)(nil)
@@ -36,53 +38,64 @@ func isSupportedPython(v string) (bool, error) {
 	return version.GreaterThan(drop), nil
 }
 
-func lookPython3() (string, error) {
-	if _, err := exec.LookPath(python3); err != nil {
+// pythonVersion returns the version of the python command, e.g. "3.10.4".
+func pythonVersion(py string) (string, error) {
+	_out, err := exec.Command(py, "--version").CombinedOutput()
+	if err != nil {
 		return "", err
 	}
-	return python3, nil
+	out := strings.TrimSpace(string(_out))
+	v := strings.Split(out, " ") // ["Python", "3.x.y"]
+	if len(v) != 2 {
+		return "", fmt.Errorf("invalid python version output %s", string(_out))
+	}
+	return v[1], nil
 }
 
-func lookPython() (string, error) {
-	if _, err := exec.LookPath(python); err != nil {
+// lookSupportedPython returns py if it is found and supported.
+func lookSupportedPython(py string) (string, error) {
+	if _, err := exec.LookPath(py); err != nil {
 		return "", err
 	}
-	_out, err := exec.Command(python, "--version").Output()
+	v, err := pythonVersion(py)
 	if err != nil {
 		return "", err
 	}
-	out := strings.TrimSpace(string(_out))
-	v := strings.Split(out, " ") // ["Python", "3.x.y"]
-	if len(v) != 2 {
-		return "", fmt.Errorf("invalid python version output %s", string(_out))
-	}
-	ok, err := isSupportedPython(v[1])
+	ok, err := isSupportedPython(v)
 	if err != nil {
 		return "", err
 	}
 	if !ok {
 		return "", fmt.Errorf("unsupported python version: %v", v)
 	}
-	return python, nil
+	return py, nil
 }
 
-func NewPipInstaller(baseDir, moduleName, binName string) *PipInstaller {
-	i := &PipInstaller{moduleName: moduleName, binName: binName}
+func lookPython3() (string, error) {
+	return lookSupportedPython(python3)
+}
+
+func lookPython() (string, error) {
+	return lookSupportedPython(python)
+}
+
+func NewPipInstaller(baseDir, name, moduleName, binName string) *PipInstaller {
+	i := &PipInstaller{name: name, moduleName: moduleName, binName: binName}
 	i.baseInstaller = newBaseInstaller(filepath.Join(baseDir, i.Name()))
 	return i
 }
 
 func _lookPython() (string, error) {
 	if !isWindows {
-		if _, err := lookPython3(); err == nil {
-			return "python3", nil
+		if py, err := lookPython3(); err == nil {
+			return py, nil
 		}
 	}
 	return lookPython()
 }
 
 func (i *PipInstaller) Name() string {
-	return i.moduleName
+	return i.name
 }
 
 func (i *PipInstaller) BinName() string {
@@ -92,14 +105,14 @@ func (i *PipInstaller) BinName() string {
 	return i.binName
 }
 
-func (i *PipInstaller) Version() string {
-	return versionUnSpecified
-}
-
 func (i *PipInstaller) Requires() []string {
 	return noRequires // use RequireHook
 }
 
+func (i *PipInstaller) Kind() string {
+	return kindPip
+}
+
 func (i *PipInstaller) RequireHook(ctx context.Context) error {
 	py, err := _lookPython()
 	if err != nil {
@@ -109,9 +122,16 @@ func (i *PipInstaller) RequireHook(ctx context.Context) error {
 	return nil
 }
 
-func (i *PipInstaller) Install(ctx context.Context) error {
-	venv := filepath.Join(i.Dir(), "venv")
-	if err := i.CmdRun(ctx, i.python, "-m", "venv", venv); err != nil {
+func (i *PipInstaller) packageSpec() string {
+	if v := i.Version(); v != versionUnSpecified {
+		return i.moduleName + "==" + v
+	}
+	return i.moduleName
+}
+
+func (i *PipInstaller) Install(ctx context.Context, dir string) error {
+	venv := filepath.Join(dir, "venv")
+	if err := i.CmdRun(ctx, dir, i.python, "-m", "venv", venv); err != nil {
 		return err
 	}
 	var bin string
@@ -121,16 +141,66 @@ func (i *PipInstaller) Install(ctx context.Context) error {
 		bin = "bin"
 	}
 	vpython := filepath.Join(venv, bin, i.python)
-	if err := i.CmdRun(ctx, vpython, "-m", "pip", "install", "--upgrade", "pip", "setuptools", "wheel"); err != nil {
+	if err := i.CmdRun(ctx, dir, vpython, "-m", "pip", "install", "--upgrade", "pip", "setuptools", "wheel"); err != nil {
 		return err
 	}
-	if err := i.CmdRun(ctx, vpython, "-m", "pip", "install", i.Name()); err != nil {
+	pkg := i.packageSpec()
+	if err := i.CmdRun(ctx, dir, vpython, i.withInstallArgs([]string{"-m", "pip", "install"}, pkg)...); err != nil {
 		return err
 	}
+	i.record("pypi:"+pkg, "")
+	if v, err := i.installedVersion(ctx, vpython); err == nil {
+		i.resolvedVersion = v
+	} else {
+		i.logger().Println(err)
+	}
 	src := filepath.Join("venv", bin, i.BinName())
-	dst := filepath.Join(i.Dir(), i.BinName())
+	dst := filepath.Join(dir, i.BinName())
 	if err := os.Symlink(src, dst); err != nil {
 		return err
 	}
 	return nil
 }
+
+func (i *PipInstaller) installedVersion(ctx context.Context, vpython string) (string, error) {
+	out, err := exec.CommandContext(ctx, vpython, "-m", "pip", "show", i.moduleName).Output()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if v := strings.TrimPrefix(line, "Version:"); v != line {
+			return strings.TrimSpace(v), nil
+		}
+	}
+	return "", fmt.Errorf("version of %s not found in pip show output", i.moduleName)
+}
+
+// relocate rewrites the scripts in the venv, whose shebangs refer to the staging directory.
+func (i *PipInstaller) relocate(from, to string) error {
+	if isWindows {
+		return nil
+	}
+	bin := filepath.Join(from, "venv", "bin")
+	files, err := ioutil.ReadDir(bin)
+	if err != nil {
+		return err
+	}
+	oldPath, newPath := []byte(from), []byte(to)
+	for _, f := range files {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		p := filepath.Join(bin, f.Name())
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if !bytes.Contains(b, oldPath) {
+			continue
+		}
+		if err := ioutil.WriteFile(p, bytes.ReplaceAll(b, oldPath, newPath), f.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package app
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -42,3 +45,33 @@ func Test_isSupportedPython(t *testing.T) {
 		})
 	}
 }
+
+func TestPipInstaller_relocate(t *testing.T) {
+	if isWindows {
+		t.Skip()
+	}
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, ".pyls.staging"), filepath.Join(dir, "pyls")
+	bin := filepath.Join(from, "venv", "bin")
+	if err := os.MkdirAll(bin, 0777); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!" + filepath.Join(from, "venv", "bin", "python3") + "\nimport pyls\n"
+	if err := ioutil.WriteFile(filepath.Join(bin, "pyls"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var i PipInstaller
+	if err := i.relocate(from, to); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(bin, "pyls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "#!"+filepath.Join(to, "venv", "bin", "python3")+"\nimport pyls\n", string(b))
+	info, err := os.Stat(filepath.Join(bin, "pyls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isExecutable(info.Mode()))
+}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+const receiptFile = "receipt.json"
+
+// Version is the version of lsm.
+// It can be set with -ldflags "-X github.com/johejo/lsm/app.Version=...".
+var Version string
+
+func lsmVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// Receipt records what was actually installed into a server directory.
+type Receipt struct {
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	Kind        string    `json:"kind"`
+	Source      string    `json:"source"`
+	Checksum    string    `json:"checksum,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+	LSMVersion  string    `json:"lsm_version"`
+}
+
+func newReceipt(i Installer) *Receipt {
+	b := i.base()
+	version := b.resolvedVersion
+	if version == versionUnSpecified {
+		version = i.Version()
+	}
+	return &Receipt{
+		Name:        i.Name(),
+		Version:     version,
+		Kind:        i.Kind(),
+		Source:      b.source,
+		Checksum:    b.checksum,
+		InstalledAt: time.Now().UTC(),
+		LSMVersion:  lsmVersion(),
+	}
+}
+
+func readReceipt(dir string) (*Receipt, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, receiptFile))
+	if err != nil {
+		return nil, err
+	}
+	var r Receipt
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func writeReceipt(dir string, r *Receipt) error {
+	b, err := json.MarshalIndent(r, "", strings.Repeat(" ", 2))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, receiptFile), b, 0666)
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Install_receipt(t *testing.T) {
+	const content = "#!/bin/sh\n"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 1.0.0
+    url: `+ts.URL+`/{{.Version}}/foo-ls
+    bin: foo-ls
+`)
+	if err := a.Install(context.Background(), "foo-ls@1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	receipt, err := readReceipt(i.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, "foo-ls", receipt.Name)
+	assert.Equal(t, "1.1.0", receipt.Version)
+	assert.Equal(t, kindArchive, receipt.Kind)
+	assert.Equal(t, ts.URL+"/1.1.0/foo-ls", receipt.Source)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), receipt.Checksum)
+	assert.False(t, receipt.InstalledAt.IsZero())
+	assert.NotEmpty(t, receipt.LSMVersion)
+
+	// list reports the installed version even if the installer is reset to the default one
+	i.SetVersion("")
+	var buf bytes.Buffer
+	a.out = &buf
+	if err := a.List(context.Background(), ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+	var list []languageServer
+	if err := json.NewDecoder(&buf).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	for _, ls := range list {
+		if ls.Name == "foo-ls" {
+			assert.True(t, ls.Installed)
+			assert.Equal(t, "1.1.0", ls.Version)
+		}
+	}
+}
+
+func TestApp_Install_versionNotInURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer ts.Close()
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 2.1.4
+    url: `+ts.URL+`/foo-2.1.4
+    bin: foo-ls
+`)
+	// another version cannot be installed from the URL, which would be recorded as the requested version
+	err := a.Install(context.Background(), "foo-ls@3.0.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not depend on the version")
+	}
+	assert.False(t, isInstalled(i))
+	assert.Equal(t, "2.1.4", i.Version())
+
+	if err := a.Install(context.Background(), "foo-ls@2.1.4"); err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := readReceipt(i.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "2.1.4", receipt.Version)
+}
@@ -0,0 +1,365 @@
+package app
+
+import (
+	"bytes"
+	_ "embed" // for builtin registry
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	kindNpm             = "npm"
+	kindPip             = "pip"
+	kindGo              = "go"
+	kindVSCodeExtension = "vscode-extension"
+	kindArchive         = "archive"
+	kindCargo           = "cargo"
+	kindGitHubRelease   = "github-release"
+
+	// only for installers defined in Go
+	kindCoursier = "coursier"
+)
+
+//go:embed registry.yaml
+var builtinRegistry []byte
+
+// Registry is a declarative list of language servers.
+// It is loaded from YAML or JSON and merged with the built-in installers.
+type Registry struct {
+	Servers []RegistryEntry `json:"servers" yaml:"servers"`
+}
+
+// RegistryEntry describes how to install a language server.
+// Package, URL, Bin, Tag, Asset, Link and ChecksumsURL are rendered as text/template with templateData.
+// An entry without Kind amends the installer with the same name.
+type RegistryEntry struct {
+	Name     string   `json:"name" yaml:"name"`
+	Kind     string   `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Package  string   `json:"package,omitempty" yaml:"package,omitempty"`
+	URL      string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Bin      string   `json:"bin,omitempty" yaml:"bin,omitempty"`
+	Version  string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Supports []string `json:"supports,omitempty" yaml:"supports,omitempty"`
+	CGO      bool     `json:"cgo,omitempty" yaml:"cgo,omitempty"`
+	// Module is the module providing Package for go, if they differ.
+	Module string `json:"module,omitempty" yaml:"module,omitempty"`
+	// Features are the crate features for cargo.
+	Features []string `json:"features,omitempty" yaml:"features,omitempty"`
+	// Prebuilt is a release binary preferred to building with cargo.
+	Prebuilt *Prebuilt `json:"prebuilt,omitempty" yaml:"prebuilt,omitempty"`
+
+	// Repo is "owner/repo" of a github-release.
+	Repo string `json:"repo,omitempty" yaml:"repo,omitempty"`
+	// Tag is the release tag, which defaults to "{{.Version}}".
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	// Asset is the name of the release asset.
+	Asset string `json:"asset,omitempty" yaml:"asset,omitempty"`
+	// Aliases rename OS and Arch, and set Ext, in the templates of github-release and archive.
+	Aliases Aliases `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	// Link is the name of the symlink to Bin, which defaults to the base name of Bin.
+	Link string `json:"link,omitempty" yaml:"link,omitempty"`
+
+	// SHA256 maps downloaded URLs or file names to their digests.
+	SHA256 map[string]string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	// ChecksumsURL is a checksums file in the sha256sum format.
+	ChecksumsURL string `json:"checksums_url,omitempty" yaml:"checksums_url,omitempty"`
+
+	// Env is added to the environment of the install commands as KEY=VALUE, e.g. NPM_CONFIG_REGISTRY or GOPRIVATE.
+	Env []string `json:"env,omitempty" yaml:"env,omitempty"`
+	// InstallArgs are added to the install command of npm, pip, go, cargo or coursier.
+	InstallArgs []string `json:"install_args,omitempty" yaml:"install_args,omitempty"`
+	// Args are passed to the language server before the arguments given at launch.
+	Args []string `json:"args,omitempty" yaml:"args,omitempty"`
+	// RuntimeEnv is added to the environment of the language server as KEY=VALUE.
+	RuntimeEnv []string `json:"runtime_env,omitempty" yaml:"runtime_env,omitempty"`
+	// StdioArgs make the language server speak LSP over the standard input and output, used by lsm check.
+	StdioArgs []string `json:"stdio_args,omitempty" yaml:"stdio_args,omitempty"`
+	// Filetypes are the editor filetypes the language server handles, e.g. "go" or "typescriptreact".
+	Filetypes []string `json:"filetypes,omitempty" yaml:"filetypes,omitempty"`
+	// RootMarkers are the files or directories that mark the root of a project, e.g. "go.mod".
+	RootMarkers []string `json:"root_markers,omitempty" yaml:"root_markers,omitempty"`
+
+	// Entrypoint is the language server in a VSIX, launched by a generated wrapper.
+	Entrypoint *Entrypoint `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty"`
+}
+
+type templateData struct {
+	Name, Version, OS, Arch, Exe string
+	// Ext is the archive extension set by the aliases.
+	Ext string
+}
+
+func (d templateData) withPlatform(s Support) templateData {
+	d.OS, d.Arch = s.os, s.arch
+	d.Exe = ""
+	if s.os == windows {
+		d.Exe = ".exe"
+	}
+	return d
+}
+
+// Aliases rename the platform in the names of release artifacts.
+type Aliases struct {
+	// OS maps GOOS to the name in artifacts, e.g. darwin to macos.
+	OS map[string]string `json:"os,omitempty" yaml:"os,omitempty"`
+	// Arch maps "os/arch" or GOARCH to the name in artifacts,
+	// e.g. arm64 to aarch64, or darwin/arm64 to universal for a universal binary.
+	Arch map[string]string `json:"arch,omitempty" yaml:"arch,omitempty"`
+	// Ext maps GOOS or "default" to the archive extension, e.g. ".tar.gz".
+	Ext map[string]string `json:"ext,omitempty" yaml:"ext,omitempty"`
+}
+
+func (a Aliases) empty() bool {
+	return len(a.OS) == 0 && len(a.Arch) == 0 && len(a.Ext) == 0
+}
+
+// withAliases sets the platform of d renamed by the aliases.
+func (d templateData) withAliases(s Support, a Aliases) templateData {
+	d = d.withPlatform(s)
+	if v, ok := a.OS[s.os]; ok {
+		d.OS = v
+	}
+	if v, ok := a.Arch[s.os+"/"+s.arch]; ok {
+		d.Arch = v
+	} else if v, ok := a.Arch[s.arch]; ok {
+		d.Arch = v
+	}
+	if v, ok := a.Ext[s.os]; ok {
+		d.Ext = v
+	} else {
+		d.Ext = a.Ext["default"]
+	}
+	return d
+}
+
+func renderTemplate(text string, data templateData) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseRegistry(b []byte, ext string) (*Registry, error) {
+	var r Registry
+	switch strings.ToLower(ext) {
+	case ".json":
+		if err := json.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(b, &r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported registry format: %q", ext)
+	}
+	for _, e := range r.Servers {
+		if err := e.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &r, nil
+}
+
+func loadRegistryFile(path string) (*Registry, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseRegistry(b, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+func parseSupport(s string) (Support, error) {
+	v := strings.Split(s, "/")
+	if len(v) != 2 || v[0] == "" || v[1] == "" {
+		return Support{}, fmt.Errorf("invalid platform %q, want os/arch", s)
+	}
+	return Support{os: v[0], arch: v[1]}, nil
+}
+
+func (e *RegistryEntry) validate() error {
+	if e.Name == "" {
+		return fmt.Errorf("registry entry without name")
+	}
+	switch e.Kind {
+	case kindNpm, kindPip, kindGo, kindCargo:
+		if e.Package == "" {
+			return fmt.Errorf("%s: package is required for %s", e.Name, e.Kind)
+		}
+		if e.Bin == "" {
+			return fmt.Errorf("%s: bin is required for %s", e.Name, e.Kind)
+		}
+	case kindVSCodeExtension, kindArchive:
+		if e.URL == "" {
+			return fmt.Errorf("%s: url is required for %s", e.Name, e.Kind)
+		}
+	case kindGitHubRelease:
+		if owner, repo := splitRepo(e.Repo); owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return fmt.Errorf("%s: repo is required as owner/repo for %s", e.Name, e.Kind)
+		}
+		if e.Asset == "" {
+			return fmt.Errorf("%s: asset is required for %s", e.Name, e.Kind)
+		}
+		if e.Bin == "" {
+			return fmt.Errorf("%s: bin is required for %s", e.Name, e.Kind)
+		}
+	case "":
+		// amends an existing installer
+	default:
+		return fmt.Errorf("%s: unknown installer kind %q", e.Name, e.Kind)
+	}
+	if e.Prebuilt != nil {
+		if e.Kind != kindCargo {
+			return fmt.Errorf("%s: prebuilt is only for %s", e.Name, kindCargo)
+		}
+		if e.Prebuilt.URL == "" {
+			return fmt.Errorf("%s: url is required for prebuilt", e.Name)
+		}
+		for _, s := range e.Prebuilt.Supports {
+			if _, err := parseSupport(s); err != nil {
+				return fmt.Errorf("%s: prebuilt: %w", e.Name, err)
+			}
+		}
+	}
+	if e.Entrypoint != nil {
+		if e.Kind != kindVSCodeExtension {
+			return fmt.Errorf("%s: entrypoint is only for %s", e.Name, kindVSCodeExtension)
+		}
+		if err := e.Entrypoint.validate(); err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+	}
+	if e.Kind != kindGitHubRelease && (e.Repo != "" || e.Tag != "" || e.Asset != "" || e.Link != "") {
+		return fmt.Errorf("%s: repo, tag, asset and link are only for %s", e.Name, kindGitHubRelease)
+	}
+	if e.Kind != kindGitHubRelease && e.Kind != kindArchive && !e.Aliases.empty() {
+		return fmt.Errorf("%s: aliases are only for %s and %s", e.Name, kindGitHubRelease, kindArchive)
+	}
+	for _, s := range e.Supports {
+		if _, err := parseSupport(s); err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+	}
+	if err := validateEnv(e.Env); err != nil {
+		return fmt.Errorf("%s: %w", e.Name, err)
+	}
+	if err := validateEnv(e.RuntimeEnv); err != nil {
+		return fmt.Errorf("%s: runtime_env: %w", e.Name, err)
+	}
+	return nil
+}
+
+func (e *RegistryEntry) supports() []Support {
+	ss := make([]Support, 0, len(e.Supports))
+	for _, s := range e.Supports {
+		support, _ := parseSupport(s) // validated
+		ss = append(ss, support)
+	}
+	return ss
+}
+
+func (e *RegistryEntry) newInstaller(baseDir string) (Installer, error) {
+	var i Installer
+	switch e.Kind {
+	case kindNpm:
+		i = NewNpmInstaller(baseDir, e.Name, e.Package, e.Bin)
+	case kindPip:
+		i = NewPipInstaller(baseDir, e.Name, e.Package, e.Bin)
+	case kindGo:
+		gi := NewGoInstaller(baseDir, e.Name, e.Package, e.Bin, e.CGO)
+		if e.Module != "" {
+			gi.module = e.Module
+		}
+		i = gi
+	case kindCargo:
+		i = NewCargoInstaller(baseDir, e.Name, e.Package, e.Bin, e.Features, e.Prebuilt)
+	case kindVSCodeExtension:
+		i = NewVSCodeExtensionInstaller(baseDir, e.Name, e.URL, e.Entrypoint)
+	case kindArchive:
+		ai := NewArchiveInstaller(baseDir, e.Name, e.URL, e.Bin)
+		ai.aliases = e.Aliases
+		i = ai
+	case kindGitHubRelease:
+		i = NewGitHubReleaseInstaller(baseDir, e.Name, e.Repo, e.Tag, e.Asset, e.Bin, e.Link, e.Aliases)
+	default:
+		return nil, fmt.Errorf("%s: unknown installer kind %q", e.Name, e.Kind)
+	}
+	if err := e.apply(i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// runsInstallCommand reports whether the installer kind installs with a package manager that takes install_args.
+func runsInstallCommand(kind string) bool {
+	switch kind {
+	case kindNpm, kindPip, kindGo, kindCargo, kindCoursier:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateEnv checks that env is a list of KEY=VALUE.
+func validateEnv(env []string) error {
+	for _, kv := range env {
+		if n := strings.Index(kv, "="); n <= 0 {
+			return fmt.Errorf("env %q is not KEY=VALUE", kv)
+		}
+	}
+	return nil
+}
+
+// apply applies the fields common to all installer kinds.
+// Env and RuntimeEnv are appended, so that later entries override earlier ones, and the others replace the current ones.
+func (e *RegistryEntry) apply(i Installer) error {
+	if len(e.InstallArgs) != 0 && !runsInstallCommand(i.Kind()) {
+		return fmt.Errorf("%s: install_args are not supported for %s", e.Name, i.Kind())
+	}
+	b := i.base()
+	b.env = append(b.env, e.Env...)
+	b.runtimeEnv = append(b.runtimeEnv, e.RuntimeEnv...)
+	if len(e.InstallArgs) != 0 {
+		b.installArgs = e.InstallArgs
+	}
+	if len(e.Args) != 0 {
+		b.args = e.Args
+	}
+	if len(e.StdioArgs) != 0 {
+		b.stdioArgs = e.StdioArgs
+	}
+	if len(e.Filetypes) != 0 {
+		b.filetypes = e.Filetypes
+	}
+	if len(e.RootMarkers) != 0 {
+		b.rootMarkers = e.RootMarkers
+	}
+	if e.Version != "" {
+		b.defaultVersion = e.Version
+	}
+	if len(e.Supports) != 0 {
+		b.supports = e.supports()
+	}
+	if len(e.SHA256) != 0 {
+		b.checksums.digests = e.SHA256
+	}
+	if e.ChecksumsURL != "" {
+		b.checksums.url = e.ChecksumsURL
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltinRegistry(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, a.installers, 28)
+	for name, i := range a.installers {
+		assert.Equal(t, name, i.Name())
+		assert.Equal(t, filepath.Join(a.baseDir, name), i.Dir())
+	}
+}
+
+func Test_parseRegistry(t *testing.T) {
+	const yml = `
+servers:
+  - name: foo-ls
+    kind: npm
+    package: "@company/foo-ls"
+    bin: foo-ls
+    version: 1.2.3
+    supports: [linux/amd64]
+`
+	const js = `{"servers": [{"name": "foo-ls", "kind": "npm", "package": "@company/foo-ls", "bin": "foo-ls", "version": "1.2.3", "supports": ["linux/amd64"]}]}`
+	want := []RegistryEntry{{
+		Name:     "foo-ls",
+		Kind:     kindNpm,
+		Package:  "@company/foo-ls",
+		Bin:      "foo-ls",
+		Version:  "1.2.3",
+		Supports: []string{"linux/amd64"},
+	}}
+	for ext, b := range map[string]string{".yaml": yml, ".json": js} {
+		r, err := parseRegistry([]byte(b), ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, want, r.Servers, ext)
+	}
+}
+
+func Test_parseRegistry_invalid(t *testing.T) {
+	tests := map[string]string{
+		"no name":          `{"servers": [{"kind": "npm", "package": "foo", "bin": "foo"}]}`,
+		"unknown kind":     `{"servers": [{"name": "foo", "kind": "brew"}]}`,
+		"no package":       `{"servers": [{"name": "foo", "kind": "go", "bin": "foo"}]}`,
+		"no url":           `{"servers": [{"name": "foo", "kind": "archive"}]}`,
+		"invalid platform": `{"servers": [{"name": "foo", "kind": "archive", "url": "https://example.com", "supports": ["linux"]}]}`,
+	}
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseRegistry([]byte(b), ".json")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApp_LoadRegistry(t *testing.T) {
+	dir := t.TempDir()
+	a, err := New(Options{BaseDir: filepath.Join(dir, "servers")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := filepath.Join(dir, "registry.yaml")
+	const b = `
+servers:
+  - name: gopls
+    kind: go
+    package: golang.org/x/tools/gopls
+    bin: gopls
+    version: v0.9.1
+  - name: in-house-ls
+    kind: pip
+    package: in-house-language-server
+    bin: in-house-ls
+`
+	if err := ioutil.WriteFile(registry, []byte(b), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.LoadRegistry(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	i, err := a.getInstaller("gopls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "v0.9.1", i.Version())
+
+	i, err = a.getInstaller("in-house-ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.IsType(t, &PipInstaller{}, i)
+	assert.Equal(t, filepath.Join(a.baseDir, "in-house-ls"), i.Dir())
+}
+
+func TestArchiveInstaller(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2.0.0/foo-ls", r.URL.Path)
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer ts.Close()
+
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 1.0.0
+    url: `+ts.URL+`/v{{.Version}}/foo-ls
+    bin: foo-ls{{.Exe}}
+`)
+	if err := a.Install(context.Background(), "foo-ls@2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(i.Dir(), i.BinName()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, isExecutable(info.Mode()))
+}
+
+func Test_templateData_withAliases(t *testing.T) {
+	aliases := Aliases{
+		OS:   map[string]string{darwin: "macos"},
+		Arch: map[string]string{amd64: "x86_64", arm64: "aarch64", "darwin/arm64": "universal"},
+		Ext:  map[string]string{windows: ".zip", "default": ".tar.gz"},
+	}
+	tests := []struct {
+		support            Support
+		os, arch, ext, exe string
+	}{
+		{Support{os: linux, arch: amd64}, linux, "x86_64", ".tar.gz", ""},
+		{Support{os: linux, arch: arm64}, linux, "aarch64", ".tar.gz", ""},
+		{Support{os: darwin, arch: arm64}, "macos", "universal", ".tar.gz", ""},
+		{Support{os: windows, arch: _386}, windows, _386, ".zip", ".exe"},
+	}
+	for _, tt := range tests {
+		d := templateData{Name: "foo-ls", Version: "1.0.0"}.withAliases(tt.support, aliases)
+		assert.Equal(t, templateData{Name: "foo-ls", Version: "1.0.0", OS: tt.os, Arch: tt.arch, Ext: tt.ext, Exe: tt.exe}, d)
+	}
+}
+
+func TestArchiveInstaller_platform(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/1.0.0/foo-ls_linux_aarch64", r.URL.Path)
+		_, _ = w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer ts.Close()
+
+	a, i := newRegistryTestApp(t, `
+servers:
+  - name: foo-ls
+    kind: archive
+    version: 1.0.0
+    url: `+ts.URL+`/{{.Version}}/foo-ls_{{.OS}}_{{.Arch}}{{.Exe}}
+    aliases:
+      arch:
+        arm64: aarch64
+    bin: foo-ls{{.Exe}}
+    supports: [linux/arm64, windows/amd64]
+`)
+	ctx := context.Background()
+
+	a.out = ioutil.Discard
+	a.SetPlatform(linux, arm64)
+	if err := a.FetchCache(ctx, []string{"foo-ls"}, ListStyleJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	// servers are installed for the host only, and the artifacts for other platforms are only fetched
+	assert.EqualError(t, a.Install(ctx, "foo-ls"), "foo-ls cannot be installed for linux/arm64, which is not the host platform; use cache fetch or bundle create to fetch it instead")
+	assert.False(t, isInstalled(i))
+
+	a.SetPlatform(darwin, arm64)
+	assert.EqualError(t, a.FetchCache(ctx, []string{"foo-ls"}, ListStyleJSON), "installer does not supports foo-ls on darwin arm64")
+	assert.EqualError(t, a.FetchCache(ctx, []string{"typescript-language-server"}, ListStyleJSON), "typescript-language-server has no artifact to fetch")
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRetries = 3
+	defaultTimeout = 30 * time.Second
+)
+
+// retryPolicy configures how requests are retried.
+// The zero value makes a single attempt without timeout.
+type retryPolicy struct {
+	// retries is the number of retries after the first attempt
+	retries int
+	// timeout aborts an attempt that receives no data for the duration
+	timeout time.Duration
+	// backoff is the wait before the first retry, doubled for each retry
+	backoff time.Duration
+	// maxWait is the longest wait for a rate limit to reset
+	maxWait time.Duration
+}
+
+func newRetryPolicy(retries int, timeout time.Duration) retryPolicy {
+	p := retryPolicy{retries: retries, timeout: timeout, backoff: time.Second, maxWait: time.Minute}
+	if p.retries == 0 {
+		p.retries = defaultRetries
+	} else if p.retries < 0 {
+		p.retries = 0
+	}
+	if p.timeout == 0 {
+		p.timeout = defaultTimeout
+	} else if p.timeout < 0 {
+		p.timeout = 0
+	}
+	return p
+}
+
+// statusError is a response with an unsuccessful status code.
+type statusError struct {
+	url  string
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GET %s: invalid status code: %v, body=%v", e.url, e.code, e.body)
+}
+
+// isNotFound reports whether err is caused by a 404 response.
+func isNotFound(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == http.StatusNotFound
+}
+
+// retryableError is a failure that may succeed if retried, after at least the duration.
+type retryableError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+func retryable(err error) error {
+	return &retryableError{err: err}
+}
+
+// run calls f until it succeeds, fails with an error that is not retryable, or runs out of retries.
+func (p retryPolicy) run(ctx context.Context, l *log.Logger, f func() error) error {
+	if l == nil {
+		l = log.Default()
+	}
+	for n := 0; ; n++ {
+		err := f()
+		var re *retryableError
+		if !errors.As(err, &re) || n >= p.retries {
+			return err
+		}
+		wait := p.backoff << n
+		if re.after > wait {
+			wait = re.after
+		}
+		l.Printf("%v, retrying in %v", err, wait)
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// watchdog cancels an attempt that receives no data for the timeout of the policy.
+type watchdog struct {
+	timer   *time.Timer
+	timeout time.Duration
+	fired   int32
+}
+
+// watch returns a context canceled by the watchdog, which is stopped by stop.
+func (p retryPolicy) watch(ctx context.Context) (context.Context, *watchdog, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watchdog{timeout: p.timeout}
+	if p.timeout <= 0 {
+		return ctx, w, cancel
+	}
+	w.timer = time.AfterFunc(p.timeout, func() {
+		atomic.StoreInt32(&w.fired, 1)
+		cancel()
+	})
+	return ctx, w, func() {
+		w.timer.Stop()
+		cancel()
+	}
+}
+
+// reader returns r that resets the watchdog whenever it reads data.
+func (w *watchdog) reader(r io.Reader) io.Reader {
+	if w.timer == nil {
+		return r
+	}
+	return &watchdogReader{r: r, w: w}
+}
+
+type watchdogReader struct {
+	r io.Reader
+	w *watchdog
+}
+
+func (r *watchdogReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.w.timer.Reset(r.w.timeout)
+	}
+	return n, err
+}
+
+// transportError classifies an error of sending a request or reading its response.
+// ctx is the one returned by watch, which is also canceled with its parent.
+func (w *watchdog) transportError(ctx context.Context, u string, err error) error {
+	if atomic.LoadInt32(&w.fired) == 1 {
+		return retryable(fmt.Errorf("GET %s: no data received for %v", u, w.timeout))
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		err = fmt.Errorf("GET %s: %w", u, err)
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return err
+	}
+	return retryable(err)
+}
+
+// rateLimitWait returns the time until the rate limit of the response resets, if the response is rate limited.
+// GitHub responds 403 or 429 with X-RateLimit-Remaining and X-RateLimit-Reset, and others 429 or 503 with Retry-After.
+func rateLimitWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+	default:
+		return 0, false
+	}
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if sec, err := strconv.Atoi(s); err == nil {
+			return time.Duration(sec) * time.Second, true
+		}
+		if t, err := http.ParseTime(s); err == nil {
+			return t.Sub(now), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if sec, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(sec, 0).Sub(now), true
+		}
+		return 0, true
+	}
+	return 0, resp.StatusCode == http.StatusTooManyRequests
+}
+
+// checkStatus returns an error of the response unless it succeeded.
+// Server errors and rate limits that reset soon enough are retryable.
+func (p retryPolicy) checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	u := resp.Request.URL.String()
+	if wait, ok := rateLimitWait(resp, time.Now()); ok {
+		err := fmt.Errorf("GET %s: rate limit exceeded", u)
+		if wait > p.maxWait {
+			return fmt.Errorf("%w, which resets in %v", err, wait.Round(time.Second))
+		}
+		return &retryableError{err: err, after: wait}
+	}
+	err = &statusError{url: u, code: resp.StatusCode, body: string(b)}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout {
+		return retryable(err)
+	}
+	return err
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const retryTestBody = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// newRetryTestServer serves retryTestBody with Range support after the faults injected for the first attempts.
+func newRetryTestServer(t *testing.T, faults ...http.HandlerFunc) (*httptest.Server, *int32, *[]string) {
+	t.Helper()
+	var (
+		count  int32
+		ranges []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		ranges = append(ranges, r.Header.Get("Range"))
+		if int(n) <= len(faults) {
+			faults[n-1](w, r)
+			return
+		}
+		http.ServeContent(w, r, "foo-ls", time.Time{}, strings.NewReader(retryTestBody))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &count, &ranges
+}
+
+func newRetryTestInstaller(t *testing.T) *baseInstaller {
+	t.Helper()
+	b := newBaseInstaller(t.TempDir())
+	b.quiet = true
+	b.retry = retryPolicy{retries: 2, timeout: time.Second, backoff: time.Millisecond, maxWait: time.Second}
+	return &b
+}
+
+func download(t *testing.T, b *baseInstaller, u string) (string, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(b.Dir(), "foo-ls")
+	if err := b.Download(req, name); err != nil {
+		return "", err
+	}
+	got, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(got), nil
+}
+
+// truncated sends the headers of the whole body and the first half of it, then closes the connection.
+func truncated(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Length", strconv.Itoa(len(retryTestBody)))
+	_, _ = w.Write([]byte(retryTestBody[:len(retryTestBody)/2]))
+	w.(http.Flusher).Flush()
+	conn, _, err := w.(http.Hijacker).Hijack()
+	if err == nil {
+		conn.Close()
+	}
+}
+
+func status(code int, header map[string]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(code)
+	}
+}
+
+func TestBaseInstaller_Download_retry(t *testing.T) {
+	t.Run("server error", func(t *testing.T) {
+		ts, count, _ := newRetryTestServer(t, status(http.StatusBadGateway, nil), status(http.StatusServiceUnavailable, nil))
+		b := newRetryTestInstaller(t)
+		var stderr strings.Builder
+		b.SetWriter(&stderr)
+		got, err := download(t, b, ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, retryTestBody, got)
+		assert.Equal(t, int32(3), *count)
+		// the retries are logged to the output of the installer
+		assert.Contains(t, stderr.String(), "invalid status code: 502, body=, retrying in 1ms\n")
+		assert.Contains(t, stderr.String(), "invalid status code: 503, body=, retrying in 2ms\n")
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		ts, count, ranges := newRetryTestServer(t, truncated)
+		got, err := download(t, newRetryTestInstaller(t), ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, retryTestBody, got)
+		assert.Equal(t, int32(2), *count)
+		assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(retryTestBody)/2)}, *ranges)
+	})
+
+	t.Run("resume ignored", func(t *testing.T) {
+		ts, _, _ := newRetryTestServer(t, truncated, func(w http.ResponseWriter, r *http.Request) {
+			// the whole body without Range support
+			_, _ = w.Write([]byte(retryTestBody))
+		})
+		got, err := download(t, newRetryTestInstaller(t), ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, retryTestBody, got)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ts, count, ranges := newRetryTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(retryTestBody)))
+			_, _ = w.Write([]byte(retryTestBody[:10]))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		})
+		b := newRetryTestInstaller(t)
+		b.retry.timeout = 50 * time.Millisecond
+		got, err := download(t, b, ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, retryTestBody, got)
+		assert.Equal(t, int32(2), *count)
+		assert.Equal(t, "bytes=10-", (*ranges)[1])
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		reset := strconv.FormatInt(time.Now().Unix(), 10)
+		ts, count, _ := newRetryTestServer(t, status(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}))
+		got, err := download(t, newRetryTestInstaller(t), ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, retryTestBody, got)
+		assert.Equal(t, int32(2), *count)
+	})
+
+	t.Run("rate limit too long", func(t *testing.T) {
+		reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		ts, count, _ := newRetryTestServer(t, status(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}))
+		_, err := download(t, newRetryTestInstaller(t), ts.URL)
+		assert.ErrorContains(t, err, "rate limit exceeded, which resets in ")
+		assert.Equal(t, int32(1), *count)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ts, count, _ := newRetryTestServer(t, status(http.StatusNotFound, nil))
+		_, err := download(t, newRetryTestInstaller(t), ts.URL)
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), *count)
+	})
+
+	t.Run("out of retries", func(t *testing.T) {
+		fault := status(http.StatusInternalServerError, nil)
+		ts, count, _ := newRetryTestServer(t, fault, fault, fault)
+		_, err := download(t, newRetryTestInstaller(t), ts.URL)
+		assert.ErrorContains(t, err, "invalid status code: 500")
+		assert.Equal(t, int32(3), *count)
+	})
+}
+
+func TestRegistryClient_get_retry(t *testing.T) {
+	ts, count, _ := newRetryTestServer(t, status(http.StatusTooManyRequests, map[string]string{"Retry-After": "0"}), truncated)
+	c := &registryClient{client: http.DefaultClient, retry: retryPolicy{retries: 2, backoff: time.Millisecond, maxWait: time.Second}}
+	b, err := c.get(context.Background(), ts.URL, "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, retryTestBody, string(b))
+	assert.Equal(t, int32(3), *count)
+}
+
+func Test_rateLimitWait(t *testing.T) {
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		code   int
+		header map[string]string
+		want   time.Duration
+		ok     bool
+	}{
+		{"github", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(now.Add(time.Minute).Unix(), 10)}, time.Minute, true},
+		{"retry after", http.StatusTooManyRequests, map[string]string{"Retry-After": "120"}, 2 * time.Minute, true},
+		{"retry after date", http.StatusServiceUnavailable, map[string]string{"Retry-After": now.Add(time.Second).Format(http.TimeFormat)}, time.Second, true},
+		{"too many requests", http.StatusTooManyRequests, nil, 0, true},
+		{"forbidden", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "10"}, 0, false},
+		{"unavailable", http.StatusServiceUnavailable, nil, 0, false},
+		{"ok", http.StatusOK, map[string]string{"Retry-After": "120"}, 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.code, Header: http.Header{}}
+		for k, v := range tt.header {
+			resp.Header.Set(k, v)
+		}
+		got, ok := rateLimitWait(resp, now)
+		assert.Equal(t, tt.want, got, tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+	}
+}
+
+func Test_newRetryPolicy(t *testing.T) {
+	p := newRetryPolicy(0, 0)
+	assert.Equal(t, defaultRetries, p.retries)
+	assert.Equal(t, defaultTimeout, p.timeout)
+	p = newRetryPolicy(-1, -1)
+	assert.Equal(t, 0, p.retries)
+	assert.Equal(t, time.Duration(0), p.timeout)
+}
@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// relocator is implemented by installers whose files refer to the absolute install directory.
+type relocator interface {
+	relocate(from, to string) error
+}
+
+func stagingDir(dir string) string {
+	return filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+".staging")
+}
+
+func backupDir(dir string) string {
+	return filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+".old")
+}
+
+// installStaged installs into a staging directory next to Dir and swaps it in
+// only after the installation and the post-install check succeed.
+// On failure the previous installation is left untouched.
+// Dir keeps pointing at the current installation while installing.
+func installStaged(ctx context.Context, i Installer) (*Receipt, error) {
+	b := i.base()
+	dir := i.Dir()
+	staging := stagingDir(dir)
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(staging, 0777); err != nil {
+		return nil, err
+	}
+
+	r, err := func() (*Receipt, error) {
+		b.resetRecord()
+		b.data = newTemplateData(i)
+		if err := i.Install(ctx, staging); err != nil {
+			return nil, err
+		}
+		if err := postInstallCheck(i, staging); err != nil {
+			return nil, err
+		}
+		if rel, ok := i.(relocator); ok {
+			if err := rel.relocate(staging, dir); err != nil {
+				return nil, err
+			}
+		}
+		r := newReceipt(i)
+		if err := writeReceipt(staging, r); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}()
+	if err != nil {
+		if err := os.RemoveAll(staging); err != nil {
+			b.logger().Println(err)
+		}
+		return nil, err
+	}
+
+	if err := swapDir(staging, dir, b.logger()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// postInstallCheck checks that the executable is installed into dir.
+func postInstallCheck(i Installer, dir string) error {
+	if i.BinName() == noExecutable {
+		return nil
+	}
+	info, err := os.Stat(filepath.Join(dir, i.BinName()))
+	if err != nil {
+		return fmt.Errorf("post-install check: %w", err)
+	}
+	if !isExecutable(info.Mode()) {
+		return fmt.Errorf("post-install check: %s is not executable", i.BinName())
+	}
+	return nil
+}
+
+// swapDir replaces dir with staging.
+// The previous dir is moved aside and restored if the replacement fails, and errors of the cleanup are logged to l.
+func swapDir(staging, dir string, l *log.Logger) error {
+	backup := backupDir(dir)
+	if err := os.RemoveAll(backup); err != nil {
+		return err
+	}
+	hasPrevious := true
+	if err := os.Rename(dir, backup); err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		hasPrevious = false
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		if hasPrevious {
+			if err := os.Rename(backup, dir); err != nil {
+				l.Println(err)
+			}
+		}
+		return err
+	}
+	if hasPrevious {
+		if err := os.RemoveAll(backup); err != nil {
+			l.Println(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_Install_rollback(t *testing.T) {
+	a, err := New(Options{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := newFakeInstaller(a.baseDir, "fake-ls")
+	a.installers[i.Name()] = i
+	if err := a.Install(context.Background(), "fake-ls@1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, stagingDir(i.Dir()), i.installedInto)
+
+	assertPrevious := func(t *testing.T) {
+		t.Helper()
+		r, err := readReceipt(i.Dir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "1.0.0", r.Version)
+		assert.True(t, isInstalled(i))
+		assert.Equal(t, filepath.Join(a.baseDir, "fake-ls"), i.Dir())
+		_, err = os.Stat(stagingDir(i.Dir()))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	t.Run("install error", func(t *testing.T) {
+		i.err = errors.New("network is down")
+		defer func() { i.err = nil }()
+		assert.Error(t, a.Install(context.Background(), "fake-ls@2.0.0"))
+		assertPrevious(t)
+	})
+
+	t.Run("post-install check error", func(t *testing.T) {
+		i.noBin = true
+		defer func() { i.noBin = false }()
+		assert.Error(t, a.Install(context.Background(), "fake-ls@2.0.0"))
+		assertPrevious(t)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		if err := a.Install(context.Background(), "fake-ls@2.0.0"); err != nil {
+			t.Fatal(err)
+		}
+		r, err := readReceipt(i.Dir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "2.0.0", r.Version)
+		files, err := ioutil.ReadDir(a.baseDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, files, 1, "staging and backup directories should be removed")
+	})
+}
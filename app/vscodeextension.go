@@ -8,16 +8,19 @@ import (
 type VSCodeExtensionInstaller struct {
 	baseInstaller
 
-	name, extensionName, vsixURL string
+	name, vsixURL string
+	entrypoint    *Entrypoint
 }
 
 var _ Installer = (*VSCodeExtensionInstaller)(nil)
 
-func NewVSCodeExtensionInstaller(baseDir, name, extensionName, vsixURL string) *VSCodeExtensionInstaller {
+// NewVSCodeExtensionInstaller returns an installer of a VSIX.
+// A wrapper script named after the language server is generated if entrypoint is not nil.
+func NewVSCodeExtensionInstaller(baseDir, name, vsixURL string, entrypoint *Entrypoint) *VSCodeExtensionInstaller {
 	i := VSCodeExtensionInstaller{
 		name:          name,
-		extensionName: extensionName,
 		vsixURL:       vsixURL,
+		entrypoint:    entrypoint,
 		baseInstaller: newBaseInstaller(filepath.Join(baseDir, name)),
 	}
 	return &i
@@ -28,17 +31,52 @@ func (i *VSCodeExtensionInstaller) Name() string {
 }
 
 func (i *VSCodeExtensionInstaller) BinName() string {
-	return noExecutable
-}
-
-func (i *VSCodeExtensionInstaller) Version() string {
-	return versionUnSpecified
+	if i.entrypoint == nil {
+		return noExecutable
+	}
+	if i.platform().os == windows {
+		return i.name + ".cmd"
+	}
+	return i.name
 }
 
+// Requires returns the runtime the entrypoint is launched with, if any.
 func (i *VSCodeExtensionInstaller) Requires() []string {
+	if i.entrypoint == nil {
+		return noRequires
+	}
+	if prog, _, _ := i.entrypoint.command(i.platform()); prog != "" {
+		return []string{prog}
+	}
 	return noRequires
 }
 
-func (i *VSCodeExtensionInstaller) Install(ctx context.Context) error {
-	return i.FetchWithExtract(ctx, i.vsixURL, filepath.Join(i.Dir(), i.extensionName+".zip"))
+func (i *VSCodeExtensionInstaller) Kind() string {
+	return kindVSCodeExtension
+}
+
+func (i *VSCodeExtensionInstaller) generatesLauncher() bool {
+	return i.entrypoint != nil
+}
+
+func (i *VSCodeExtensionInstaller) setURL(u string) {
+	i.vsixURL = u
+}
+
+func (i *VSCodeExtensionInstaller) artifactURL(s Support) (string, error) {
+	return renderTemplate(i.vsixURL, i.templateData(i.Name()).withPlatform(s))
+}
+
+func (i *VSCodeExtensionInstaller) Install(ctx context.Context, dir string) error {
+	u, err := i.artifactURL(i.platform())
+	if err != nil {
+		return err
+	}
+	if err := i.FetchWithExtract(ctx, u, filepath.Join(dir, i.Name()+".zip")); err != nil {
+		return err
+	}
+	if i.entrypoint == nil {
+		return nil
+	}
+	return i.entrypoint.writeWrapper(dir, i.BinName(), i.platform(), i.args, i.runtimeEnv)
 }
@@ -0,0 +1,91 @@
+/*
+Copyright © 2020 Mitsuo Heijo <mitsuo.heijo@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/johejo/lsm/app"
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "package language servers for installation without network access",
+}
+
+// bundleCreateCmd represents the bundle create command
+var bundleCreateCmd = &cobra.Command{
+	Use:     "create",
+	Short:   "download language servers into a bundle",
+	Example: "  lsm bundle create --servers efm-langserver,terraform-ls@0.29.0 --os linux --arch amd64 -o bundle.tar.zst",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(bundleServers) == 0 {
+			return errors.New("requires --servers")
+		}
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		a.SetInsecureSkipVerify(insecureSkipVerify)
+		a.SetPlatform(targetOS, targetArch)
+		return a.CreateBundle(cmd.Context(), bundleServers, bundleFile)
+	},
+}
+
+// bundleInstallCmd represents the bundle install command
+var bundleInstallCmd = &cobra.Command{
+	Use:     "install <bundle>",
+	Short:   "install language servers from a bundle without network access",
+	Example: "  lsm bundle install bundle.tar.zst",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		if err := a.MigrateLegacyDirs(); err != nil {
+			return err
+		}
+		return a.InstallBundle(cmd.Context(), args[0], jobs, app.ListStyle(output))
+	},
+}
+
+var (
+	bundleServers []string
+	bundleFile    string
+)
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleCreateCmd, bundleInstallCmd)
+	bundleCreateCmd.Flags().StringSliceVar(&bundleServers, "servers", nil, "language servers to bundle as name[@version]")
+	bundleCreateCmd.Flags().StringVarP(&bundleFile, "output", "o", "bundle.tar.zst", "bundle file, whose extension selects the archive format")
+	bundleCreateCmd.Flags().StringVar(&targetOS, "os", "", "operating system to bundle for (default is the host)")
+	bundleCreateCmd.Flags().StringVar(&targetArch, "arch", "", "architecture to bundle for (default is the host)")
+	bundleCreateCmd.Flags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "skip checksum verification of downloaded files")
+	bundleInstallCmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "number of language servers installed in parallel, which defaults to jobs in the config file or 4")
+	bundleInstallCmd.Flags().StringVarP(&output, "output", "o", "", `output style of the summary ("json", "table"), which defaults to output in the config file or "table"`)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2020 Mitsuo Heijo <mitsuo.heijo@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/johejo/lsm/app"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "manage the download cache",
+}
+
+// cacheListCmd represents the cache list command
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list cached downloads",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		return a.ListCache(cmd.Context(), app.ListStyle(output))
+	},
+}
+
+// cacheFetchCmd represents the cache fetch command
+var cacheFetchCmd = &cobra.Command{
+	Use:     "fetch <name>[@version]...",
+	Short:   "download artifacts into the cache without installing them",
+	Example: "  lsm cache fetch --os linux --arch arm64 efm-langserver terraform-ls@0.29.0",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		a.SetInsecureSkipVerify(insecureSkipVerify)
+		a.SetPlatform(targetOS, targetArch)
+		return a.FetchCache(cmd.Context(), args, app.ListStyle(output))
+	},
+}
+
+// cacheCleanCmd represents the cache clean command
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "remove all cached downloads",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		return a.CleanCache(cmd.Context())
+	},
+}
+
+// cachePruneCmd represents the cache prune command
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "remove cached downloads that no installed language server refers to",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		return a.PruneCache(cmd.Context(), app.ListStyle(output))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cacheFetchCmd, cacheCleanCmd, cachePruneCmd)
+	cacheFetchCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
+	cacheFetchCmd.Flags().StringVar(&targetOS, "os", "", "operating system to fetch for (default is the host)")
+	cacheFetchCmd.Flags().StringVar(&targetArch, "arch", "", "architecture to fetch for (default is the host)")
+	cacheFetchCmd.Flags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "skip checksum verification of downloaded files")
+	cacheListCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
+	cachePruneCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2020 Mitsuo Heijo <mitsuo.heijo@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/johejo/lsm/app"
+)
+
+// capabilitiesCmd represents the capabilities command
+var capabilitiesCmd = &cobra.Command{
+	Use:     "capabilities [name]...",
+	Aliases: []string{"caps"},
+	Short:   "show a matrix of the LSP features installed language servers advertise",
+	Long:    "Initialize installed language servers, or all of them if no name is given, as check does, and show a matrix of the LSP features they advertise.",
+	Example: "  lsm capabilities terraform-ls terraform-lsp",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		return a.Capabilities(cmd.Context(), args, checkTimeout, app.ListStyle(output))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(capabilitiesCmd)
+	capabilitiesCmd.Flags().DurationVar(&checkTimeout, "timeout", app.DefaultCheckTimeout, "time limit of initializing each language server")
+	capabilitiesCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2020 Mitsuo Heijo <mitsuo.heijo@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/johejo/lsm/app"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:     "check [name]...",
+	Short:   "launch installed language servers and check that they initialize over stdio",
+	Long:    "Launch installed language servers, or all of them if no name is given, and check that they initialize and shut down over stdio.\nThe server names, versions and capabilities they advertise are shown.",
+	Example: "  lsm check gopls typescript-language-server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		return a.Check(cmd.Context(), args, checkTimeout, app.ListStyle(output))
+	},
+}
+
+var checkTimeout time.Duration
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().DurationVar(&checkTimeout, "timeout", app.DefaultCheckTimeout, "time limit of each check")
+	checkCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
+}
@@ -31,9 +31,10 @@ import (
 
 // installCmd represents the install command
 var installCmd = &cobra.Command{
-	Use:     "install",
+	Use:     "install <name>[@version]...",
 	Aliases: []string{"i"},
 	Short:   "Install specified language server",
+	Example: "  lsm install gopls@v0.9.1 rust-analyzer",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return errors.New("requires a language server argument")
@@ -41,21 +42,39 @@ var installCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		a, err := app.New("")
+		a, err := newApp()
 		if err != nil {
 			return err
 		}
-		for _, arg := range args {
-			if err := a.Install(cmd.Context(), arg); err != nil {
+		if err := a.MigrateLegacyDirs(); err != nil {
+			return err
+		}
+		a.SetInsecureSkipVerify(insecureSkipVerify)
+		if frozen {
+			l, err := app.LoadLock(app.LockFile)
+			if err != nil {
 				return err
 			}
+			a.SetFrozen(l)
 		}
-		return nil
+		return a.InstallAll(cmd.Context(), args, jobs, app.ListStyle(output))
 	},
 }
 
+var (
+	insecureSkipVerify bool
+	frozen             bool
+	jobs               int
+	targetOS           string
+	targetArch         string
+)
+
 func init() {
 	rootCmd.AddCommand(installCmd)
+	installCmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "number of language servers installed in parallel, which defaults to jobs in the config file or 4")
+	installCmd.Flags().StringVarP(&output, "output", "o", "", `output style of the summary ("json", "table"), which defaults to output in the config file or "table"`)
+	installCmd.Flags().BoolVar(&frozen, "frozen", false, "refuse to install anything that deviates from "+app.LockFile)
+	installCmd.Flags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "skip checksum verification of downloaded files")
 
 	// Here you will define your flags and configuration settings.
 
@@ -31,7 +31,7 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "show language server list",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		a, err := app.New("")
+		a, err := newApp()
 		if err != nil {
 			return err
 		}
@@ -46,9 +46,11 @@ var (
 	output string
 )
 
+const outputUsage = `output style ("json", "table"), which defaults to output in the config file or "table"`
+
 func init() {
 	rootCmd.AddCommand(listCmd)
-	listCmd.Flags().StringVarP(&output, "output", "o", "table", `output style ("json", "table")`)
+	listCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
 
 	// Here you will define your flags and configuration settings.
 
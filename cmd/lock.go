@@ -0,0 +1,62 @@
+/*
+Copyright © 2020 Mitsuo Heijo <mitsuo.heijo@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/johejo/lsm/app"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "write " + app.LockFile + " with the resolved versions and digests of the project manifest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := app.LoadManifest(manifestFile)
+		if err != nil {
+			return err
+		}
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		l, err := a.Lock(cmd.Context(), m)
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(filepath.Dir(manifestFile), app.LockFile)
+		if err := app.WriteLock(p, l); err != nil {
+			return err
+		}
+		log.Printf("%s written", p)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	lockCmd.Flags().StringVarP(&manifestFile, "file", "f", app.ManifestFile, "project manifest")
+}
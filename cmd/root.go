@@ -27,9 +27,17 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/johejo/lsm/app"
 )
 
-var cfgFile string
+var (
+	cfgFile    string
+	registries []string
+
+	// config is separated by "::" instead of "." since server names such as eclipse.jdt.ls contain dots.
+	config = viper.NewWithOptions(viper.KeyDelimiter("::"))
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -63,6 +71,7 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.lsm.yaml)")
+	rootCmd.PersistentFlags().StringSliceVar(&registries, "registry", nil, "additional registry files (YAML or JSON)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -73,7 +82,7 @@ func init() {
 func initConfig() {
 	if cfgFile != "" {
 		// Use config file from the flag.
-		viper.SetConfigFile(cfgFile)
+		config.SetConfigFile(cfgFile)
 	} else {
 		// Find home directory.
 		home, err := os.UserHomeDir()
@@ -83,14 +92,35 @@ func initConfig() {
 		}
 
 		// Search config in home directory with name ".lsm" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".lsm")
+		config.AddConfigPath(home)
+		config.SetConfigName(".lsm")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// read in environment variables such as LSM_BASE_DIR
+	config.SetEnvPrefix("lsm")
+	for _, key := range []string{"base_dir", "cache_dir", "output", "proxy", "jobs", "retries", "timeout", "registries"} {
+		if err := config.BindEnv(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
 
 	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	if err := config.ReadInConfig(); err == nil {
+		// stderr, since stdout may be the stdio of a language server
+		fmt.Fprintln(os.Stderr, "Using config file:", config.ConfigFileUsed())
+	} else if cfgFile != "" {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// newApp creates app.App with the options in the config file and the additional registries given by flags.
+func newApp() (*app.App, error) {
+	var opts app.Options
+	if err := config.Unmarshal(&opts); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
+	opts.Registries = append(opts.Registries, registries...)
+	return app.New(opts)
 }
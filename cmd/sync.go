@@ -0,0 +1,70 @@
+/*
+Copyright © 2020 Mitsuo Heijo <mitsuo.heijo@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/johejo/lsm/app"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "install, upgrade or downgrade language servers to match the project manifest",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := app.LoadManifest(manifestFile)
+		if err != nil {
+			return err
+		}
+		a, err := newApp()
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			if err := a.MigrateLegacyDirs(); err != nil {
+				return err
+			}
+		}
+		a.SetInsecureSkipVerify(insecureSkipVerify)
+		return a.Sync(cmd.Context(), m, app.SyncOptions{
+			DryRun: dryRun,
+			Prune:  prune,
+			Style:  app.ListStyle(output),
+		})
+	},
+}
+
+var (
+	manifestFile string
+	dryRun       bool
+	prune        bool
+)
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringVarP(&manifestFile, "file", "f", app.ManifestFile, "project manifest")
+	syncCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show the plan")
+	syncCmd.Flags().BoolVar(&prune, "prune", false, "uninstall language servers that are not listed in the manifest")
+	syncCmd.Flags().StringVarP(&output, "output", "o", "", outputUsage)
+	syncCmd.Flags().BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "skip checksum verification of downloaded files")
+}
@@ -23,8 +23,6 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
-
-	"github.com/johejo/lsm/app"
 )
 
 // uninstallCmd represents the uninstall command
@@ -32,7 +30,7 @@ var uninstallCmd = &cobra.Command{
 	Use:   "uninstall",
 	Short: "uninstall specified language server",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		a, err := app.New("")
+		a, err := newApp()
 		if err != nil {
 			return err
 		}